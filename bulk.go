@@ -0,0 +1,750 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+func (c *constructor) bulkElem(typ types.Type) bool {
+	if named, ok := typ.(*types.Named); ok {
+		if _, ok := c.types[named.Origin()]; ok || stdlibFunc(named) != "" || binaryFunc(named, true) != "" || binaryFunc(named, false) != "" {
+			return false
+		}
+	}
+
+	t, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+
+	switch t.Kind() {
+	case types.Int8, types.Uint8, types.Float32, types.Float64:
+		return true
+	case types.Int16, types.Int32, types.Int64, types.Uint16, types.Uint32, types.Uint64:
+		return !c.varint
+	}
+
+	return false
+}
+
+func (c *constructor) writeBulk(name ast.Expr, elem types.Type) bool {
+	if _, ok := elem.(*types.TypeParam); ok || !c.bulkElem(elem) {
+		return false
+	} else if c.sizing {
+		c.addSize(mulSize(lenCall(name), sizes.Sizeof(elem.Underlying())))
+
+		return true
+	}
+
+	if types.Identical(elem, types.Typ[types.Uint8]) {
+		c.addWriter("Write", name)
+
+		return true
+	}
+
+	c.helpers["_bulk"] = true
+
+	c.addStatement(&ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: ast.NewIdent("_write_bulk"),
+			Args: []ast.Expr{
+				ast.NewIdent("w"),
+				name,
+			},
+		},
+	})
+
+	return true
+}
+
+func (c *constructor) readBulk(name ast.Expr, elem types.Type) bool {
+	if _, ok := elem.(*types.TypeParam); ok || !c.bulkElem(elem) {
+		return false
+	}
+
+	result := c.decodeError(ast.NewIdent("err"))
+
+	if types.Identical(elem, types.Typ[types.Uint8]) {
+		c.helpers["_read_full"] = true
+
+		c.addStatement(readBulkCall("_read_full", result, ast.NewIdent("r"), name, intLit(1)))
+	} else {
+		c.helpers["_bulk"] = true
+
+		c.addStatement(readBulkCall("_read_bulk", result, ast.NewIdent("r"), name))
+	}
+
+	return true
+}
+
+func readBulkCall(fn string, result ast.Expr, args ...ast.Expr) ast.Stmt {
+	return &ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  ast.NewIdent(fn),
+					Args: args,
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						result,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) bulkDecls() []ast.Decl {
+	var decls []ast.Decl
+
+	if c.helpers["_read_full"] || c.helpers["_bulk"] {
+		c.use("io")
+
+		decls = append(decls, readFullFunc())
+	}
+
+	if !c.helpers["_bulk"] {
+		return decls
+	}
+
+	c.use("encoding/binary")
+	c.use("slices")
+	c.use("unsafe")
+
+	terms := []types.BasicKind{types.Int8, types.Int16, types.Int32, types.Int64, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Float32, types.Float64}
+
+	var union ast.Expr
+
+	for _, kind := range terms {
+		term := &ast.UnaryExpr{
+			Op: token.TILDE,
+			X:  ast.NewIdent(types.Typ[kind].Name()),
+		}
+
+		if union == nil {
+			union = term
+		} else {
+			union = &ast.BinaryExpr{
+				X:  union,
+				Op: token.OR,
+				Y:  term,
+			}
+		}
+	}
+
+	return append(decls,
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: ast.NewIdent("_bulk"),
+					Type: &ast.InterfaceType{
+						Methods: &ast.FieldList{
+							List: []*ast.Field{
+								{
+									Type: union,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		bulkFunc("_write_bulk", "W", "StickyWriter", "w", nil, []ast.Stmt{
+			&ast.IfStmt{
+				Cond: swapCond("w"),
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.AssignStmt{
+							Lhs: []ast.Expr{
+								ast.NewIdent("b"),
+							},
+							Tok: token.ASSIGN,
+							Rhs: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   ast.NewIdent("slices"),
+										Sel: ast.NewIdent("Clone"),
+									},
+									Args: []ast.Expr{
+										ast.NewIdent("b"),
+									},
+								},
+							},
+						},
+						swapCall(),
+					},
+				},
+			},
+			&ast.ExprStmt{
+				X: &ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent("w"),
+						Sel: ast.NewIdent("Write"),
+					},
+					Args: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+				},
+			},
+		}),
+		bulkFunc("_read_bulk", "R", "StickyReader", "r", &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Type: ast.NewIdent("error"),
+				},
+			},
+		}, []ast.Stmt{
+			readBulkCall("_read_full", ast.NewIdent("err"), ast.NewIdent("r"), ast.NewIdent("b"), ast.NewIdent("size")),
+			&ast.IfStmt{
+				Cond: swapCond("r"),
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						swapCall(),
+					},
+				},
+			},
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					ast.NewIdent("nil"),
+				},
+			},
+		}),
+		swapOrderFunc(),
+		swapBytesFunc(),
+	)
+}
+
+func readFullFunc() *ast.FuncDecl {
+	n := ast.NewIdent("n")
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_read_full"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("R"),
+						},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent("StickyReader"),
+						},
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("r"),
+						},
+						Type: ast.NewIdent("R"),
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("b"),
+						},
+						Type: &ast.ArrayType{
+							Elt: ast.NewIdent("byte"),
+						},
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("size"),
+						},
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						n,
+						ast.NewIdent("err"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent("io"),
+								Sel: ast.NewIdent("ReadFull"),
+							},
+							Args: []ast.Expr{
+								ast.NewIdent("r"),
+								ast.NewIdent("b"),
+							},
+						},
+					},
+				},
+				&ast.TypeSwitchStmt{
+					Assign: &ast.ExprStmt{
+						X: &ast.TypeAssertExpr{
+							X: &ast.CallExpr{
+								Fun: ast.NewIdent("any"),
+								Args: []ast.Expr{
+									ast.NewIdent("r"),
+								},
+							},
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.CaseClause{
+								List: []ast.Expr{
+									&ast.StarExpr{
+										X: &ast.SelectorExpr{
+											X:   ast.NewIdent("byteio"),
+											Sel: ast.NewIdent("MemLittleEndian"),
+										},
+									},
+									&ast.StarExpr{
+										X: &ast.SelectorExpr{
+											X:   ast.NewIdent("byteio"),
+											Sel: ast.NewIdent("MemBigEndian"),
+										},
+									},
+								},
+								Body: []ast.Stmt{
+									&ast.ExprStmt{
+										X: &ast.CallExpr{
+											Fun: ast.NewIdent("clear"),
+											Args: []ast.Expr{
+												&ast.SliceExpr{
+													X: ast.NewIdent("b"),
+													Low: &ast.BinaryExpr{
+														X:  n,
+														Op: token.SUB,
+														Y: &ast.BinaryExpr{
+															X:  n,
+															Op: token.REM,
+															Y:  ast.NewIdent("size"),
+														},
+													},
+												},
+											},
+										},
+									},
+									&ast.ReturnStmt{
+										Results: []ast.Expr{
+											ast.NewIdent("nil"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("err"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func swapCond(stream string) ast.Expr {
+	return &ast.BinaryExpr{
+		X: &ast.BinaryExpr{
+			X:  ast.NewIdent("size"),
+			Op: token.GTR,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "1",
+			},
+		},
+		Op: token.LAND,
+		Y: &ast.CallExpr{
+			Fun: ast.NewIdent("_swap_order"),
+			Args: []ast.Expr{
+				ast.NewIdent(stream),
+			},
+		},
+	}
+}
+
+func swapCall() ast.Stmt {
+	return &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: ast.NewIdent("_swap_bytes"),
+			Args: []ast.Expr{
+				ast.NewIdent("b"),
+				ast.NewIdent("size"),
+			},
+		},
+	}
+}
+
+func bulkFunc(name, typeParam, constraint, stream string, results *ast.FieldList, body []ast.Stmt) *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(name),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent(typeParam),
+						},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent(constraint),
+						},
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("T"),
+						},
+						Type: ast.NewIdent("_bulk"),
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent(stream),
+						},
+						Type: ast.NewIdent(typeParam),
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("s"),
+						},
+						Type: &ast.ArrayType{
+							Elt: ast.NewIdent("T"),
+						},
+					},
+				},
+			},
+			Results: results,
+		},
+		Body: &ast.BlockStmt{
+			List: append([]ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("size"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: ast.NewIdent("int"),
+							Args: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   ast.NewIdent("unsafe"),
+										Sel: ast.NewIdent("Sizeof"),
+									},
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun: ast.NewIdent("T"),
+											Args: []ast.Expr{
+												&ast.BasicLit{
+													Kind:  token.INT,
+													Value: "0",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent("unsafe"),
+								Sel: ast.NewIdent("Slice"),
+							},
+							Args: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.ParenExpr{
+										X: &ast.StarExpr{
+											X: ast.NewIdent("byte"),
+										},
+									},
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   ast.NewIdent("unsafe"),
+												Sel: ast.NewIdent("Pointer"),
+											},
+											Args: []ast.Expr{
+												&ast.CallExpr{
+													Fun: &ast.SelectorExpr{
+														X:   ast.NewIdent("unsafe"),
+														Sel: ast.NewIdent("SliceData"),
+													},
+													Args: []ast.Expr{
+														ast.NewIdent("s"),
+													},
+												},
+											},
+										},
+									},
+								},
+								&ast.BinaryExpr{
+									X: &ast.CallExpr{
+										Fun: ast.NewIdent("len"),
+										Args: []ast.Expr{
+											ast.NewIdent("s"),
+										},
+									},
+									Op: token.MUL,
+									Y:  ast.NewIdent("size"),
+								},
+							},
+						},
+					},
+				},
+			}, body...),
+		},
+	}
+}
+
+func swapOrderFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_swap_order"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("stream"),
+						},
+						Type: ast.NewIdent("any"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("bool"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{
+									ast.NewIdent("big"),
+								},
+								Type: ast.NewIdent("bool"),
+							},
+						},
+					},
+				},
+				&ast.TypeSwitchStmt{
+					Assign: &ast.ExprStmt{
+						X: &ast.TypeAssertExpr{
+							X: ast.NewIdent("stream"),
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.CaseClause{
+								List: []ast.Expr{
+									&ast.StarExpr{
+										X: &ast.SelectorExpr{
+											X:   ast.NewIdent("byteio"),
+											Sel: ast.NewIdent("MemBigEndian"),
+										},
+									},
+									&ast.StarExpr{
+										X: &ast.SelectorExpr{
+											X:   ast.NewIdent("byteio"),
+											Sel: ast.NewIdent("StickyBigEndianReader"),
+										},
+									},
+									&ast.StarExpr{
+										X: &ast.SelectorExpr{
+											X:   ast.NewIdent("byteio"),
+											Sel: ast.NewIdent("StickyBigEndianWriter"),
+										},
+									},
+								},
+								Body: []ast.Stmt{
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											ast.NewIdent("big"),
+										},
+										Tok: token.ASSIGN,
+										Rhs: []ast.Expr{
+											ast.NewIdent("true"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.BinaryExpr{
+							X:  ast.NewIdent("big"),
+							Op: token.NEQ,
+							Y: &ast.ParenExpr{
+								X: &ast.BinaryExpr{
+									X: &ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X: &ast.SelectorExpr{
+												X:   ast.NewIdent("binary"),
+												Sel: ast.NewIdent("NativeEndian"),
+											},
+											Sel: ast.NewIdent("Uint16"),
+										},
+										Args: []ast.Expr{
+											&ast.CompositeLit{
+												Type: &ast.ArrayType{
+													Elt: ast.NewIdent("byte"),
+												},
+												Elts: []ast.Expr{
+													&ast.BasicLit{
+														Kind:  token.INT,
+														Value: "0",
+													},
+													&ast.BasicLit{
+														Kind:  token.INT,
+														Value: "1",
+													},
+												},
+											},
+										},
+									},
+									Op: token.EQL,
+									Y: &ast.BasicLit{
+										Kind:  token.INT,
+										Value: "1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func swapBytesFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_swap_bytes"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("b"),
+						},
+						Type: &ast.ArrayType{
+							Elt: ast.NewIdent("byte"),
+						},
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("size"),
+						},
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ForStmt{
+					Init: &ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("n"),
+						},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.BasicLit{
+								Kind:  token.INT,
+								Value: "0",
+							},
+						},
+					},
+					Cond: &ast.BinaryExpr{
+						X:  ast.NewIdent("n"),
+						Op: token.LSS,
+						Y: &ast.CallExpr{
+							Fun: ast.NewIdent("len"),
+							Args: []ast.Expr{
+								ast.NewIdent("b"),
+							},
+						},
+					},
+					Post: &ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("n"),
+						},
+						Tok: token.ADD_ASSIGN,
+						Rhs: []ast.Expr{
+							ast.NewIdent("size"),
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ExprStmt{
+								X: &ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   ast.NewIdent("slices"),
+										Sel: ast.NewIdent("Reverse"),
+									},
+									Args: []ast.Expr{
+										&ast.SliceExpr{
+											X:   ast.NewIdent("b"),
+											Low: ast.NewIdent("n"),
+											High: &ast.BinaryExpr{
+												X:  ast.NewIdent("n"),
+												Op: token.ADD,
+												Y:  ast.NewIdent("size"),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const directivePrefix = "//marshal:"
+
+type directives map[string][]string
+
+func parseDirectives(dir, ignore string) (directives, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	d := make(directives)
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == filepath.Base(ignore) {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+
+				d.add(ts.Name.Name, doc)
+			}
+		}
+	}
+
+	return d, nil
+}
+
+func (d directives) add(typeName string, doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+
+	for _, comment := range doc.List {
+		if directive, ok := strings.CutPrefix(comment.Text, directivePrefix); ok {
+			d[typeName] = append(d[typeName], strings.TrimSpace(directive))
+		}
+	}
+}
+
+func (c config) apply(typeName string, d []string) (config, error) {
+	for _, directive := range d {
+		switch name, arg, _ := strings.Cut(directive, " "); name {
+		case "bigendian":
+			c.bigEndian = true
+		case "littleendian":
+			c.bigEndian = false
+		case "varint":
+			c.varint = true
+		case "fixed":
+			c.varint = false
+		case "sortkeys":
+			c.sortKeys = true
+		case "skipunsupported":
+			c.skip = true
+		case "strict":
+			c.strict = true
+		case "tagged":
+			c.tagged = true
+		case "positional":
+			c.tagged = false
+		case "omitzero":
+			c.omitZero = true
+		case "keepnil":
+			c.keepNil = true
+		case "zerocopy":
+			c.zeroCopy = true
+		case "reuse":
+			c.reuse = true
+		case "unexported":
+			c.unexported = true
+		case "version":
+			v, err := strconv.ParseUint(strings.TrimSpace(arg), 10, 64)
+			if err != nil || v == 0 {
+				return c, fmt.Errorf("%w: %s: %q", ErrInvalidVersion, typeName, arg)
+			}
+
+			c.version = v
+		case "union":
+		default:
+			return c, fmt.Errorf("%w: %s: %s", ErrUnknownDirective, typeName, directive)
+		}
+	}
+
+	return c, nil
+}
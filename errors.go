@@ -0,0 +1,776 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+)
+
+type pathPart struct {
+	name       string
+	index, key ast.Expr
+}
+
+func (c *constructor) pushPath(part pathPart) []pathPart {
+	errPath := c.errPath
+	c.errPath = append(slices.Clip(errPath), part)
+
+	return errPath
+}
+
+func (c *constructor) pathExpr() ast.Expr {
+	var (
+		parts []ast.Expr
+		lit   string
+	)
+
+	flush := func() {
+		if lit != "" {
+			parts = append(parts, &ast.BasicLit{
+				Kind:  token.STRING,
+				Value: strconv.Quote(lit),
+			})
+		}
+
+		lit = ""
+	}
+
+	for _, part := range c.errPath {
+		switch {
+		case part.index != nil:
+			c.use("strconv")
+
+			lit += "["
+
+			flush()
+
+			parts = append(parts, &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("strconv"),
+					Sel: ast.NewIdent("Itoa"),
+				},
+				Args: []ast.Expr{part.index},
+			})
+			lit = "]"
+		case part.key != nil:
+			c.use("fmt")
+
+			lit += "["
+
+			flush()
+
+			parts = append(parts, &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("fmt"),
+					Sel: ast.NewIdent("Sprint"),
+				},
+				Args: []ast.Expr{part.key},
+			})
+			lit = "]"
+		default:
+			lit += part.name
+		}
+	}
+
+	flush()
+
+	if len(parts) == 0 {
+		return &ast.BasicLit{
+			Kind:  token.STRING,
+			Value: `""`,
+		}
+	}
+
+	expr := parts[0]
+
+	for _, part := range parts[1:] {
+		expr = &ast.BinaryExpr{
+			X:  expr,
+			Op: token.ADD,
+			Y:  part,
+		}
+	}
+
+	return expr
+}
+
+func (c *constructor) decodeError(err ast.Expr) ast.Expr {
+	c.helpers["DecodeError"] = true
+
+	return &ast.CallExpr{
+		Fun: ast.NewIdent("_decode_error"),
+		Args: []ast.Expr{
+			c.pathExpr(),
+			err,
+		},
+	}
+}
+
+func (c *constructor) decodeDone(err, offset ast.Expr) ast.Expr {
+	c.helpers["DecodeError"] = true
+
+	return &ast.CallExpr{
+		Fun: ast.NewIdent("_decode_done"),
+		Args: []ast.Expr{
+			err,
+			offset,
+		},
+	}
+}
+
+func composite(typ types.Type) bool {
+	_, basic := typ.Underlying().(*types.Basic)
+
+	return !basic
+}
+
+func (c *constructor) checkReader() {
+	if !c.unchecked {
+		return
+	}
+
+	c.unchecked = false
+
+	var err ast.Expr = &ast.CallExpr{
+		Fun: ast.NewIdent("_read_error"),
+		Args: []ast.Expr{
+			ast.NewIdent("r"),
+		},
+	}
+
+	if c.limits.enabled() {
+		c.use("cmp")
+
+		err = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("cmp"),
+				Sel: ast.NewIdent("Or"),
+			},
+			Args: []ast.Expr{
+				err,
+				&ast.SelectorExpr{
+					X:   ast.NewIdent("l"),
+					Sel: ast.NewIdent("err"),
+				},
+			},
+		}
+	}
+
+	c.addStatement(&ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				err,
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						c.decodeError(ast.NewIdent("err")),
+					},
+				},
+			},
+		},
+	})
+}
+
+func (c *constructor) decodeErrorDecls() []ast.Decl {
+	c.use("fmt")
+	c.use("io")
+	c.use("strings")
+
+	return []ast.Decl{
+		&ast.GenDecl{
+			Doc: &ast.CommentGroup{
+				List: []*ast.Comment{
+					{
+						Slash: c.newLine(),
+						Text:  "// DecodeError is returned when decoding fails, recording the path of the struct,\n// element or entry being decoded and the number of bytes read before the failure.",
+					},
+				},
+			},
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: ast.NewIdent("DecodeError"),
+					Type: &ast.StructType{
+						Fields: &ast.FieldList{
+							List: []*ast.Field{
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("Path"),
+									},
+									Type: ast.NewIdent("string"),
+								},
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("Offset"),
+									},
+									Type: ast.NewIdent("int64"),
+								},
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("Err"),
+									},
+									Type: ast.NewIdent("error"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.FuncDecl{
+			Recv: decodeErrorRecv(),
+			Name: ast.NewIdent("Error"),
+			Type: &ast.FuncType{
+				Func: c.newLine(),
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Type: ast.NewIdent("string"),
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.IfStmt{
+						Cond: &ast.BinaryExpr{
+							X: &ast.SelectorExpr{
+								X:   ast.NewIdent("e"),
+								Sel: ast.NewIdent("Path"),
+							},
+							Op: token.EQL,
+							Y: &ast.BasicLit{
+								Kind:  token.STRING,
+								Value: `""`,
+							},
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.ReturnStmt{
+									Results: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   ast.NewIdent("fmt"),
+												Sel: ast.NewIdent("Sprintf"),
+											},
+											Args: []ast.Expr{
+												&ast.BasicLit{
+													Kind:  token.STRING,
+													Value: `"error decoding at offset %d: %v"`,
+												},
+												&ast.SelectorExpr{
+													X:   ast.NewIdent("e"),
+													Sel: ast.NewIdent("Offset"),
+												},
+												&ast.SelectorExpr{
+													X:   ast.NewIdent("e"),
+													Sel: ast.NewIdent("Err"),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					&ast.ReturnStmt{
+						Return: c.newLine(),
+						Results: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   ast.NewIdent("fmt"),
+									Sel: ast.NewIdent("Sprintf"),
+								},
+								Args: []ast.Expr{
+									&ast.BasicLit{
+										Kind:  token.STRING,
+										Value: `"error decoding %s at offset %d: %v"`,
+									},
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("e"),
+										Sel: ast.NewIdent("Path"),
+									},
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("e"),
+										Sel: ast.NewIdent("Offset"),
+									},
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("e"),
+										Sel: ast.NewIdent("Err"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.FuncDecl{
+			Recv: decodeErrorRecv(),
+			Name: ast.NewIdent("Unwrap"),
+			Type: &ast.FuncType{
+				Func: c.newLine(),
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Type: ast.NewIdent("error"),
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							&ast.SelectorExpr{
+								X:   ast.NewIdent("e"),
+								Sel: ast.NewIdent("Err"),
+							},
+						},
+					},
+				},
+			},
+		},
+		c.readErrorFunc(),
+		c.decodeErrorFunc(),
+		c.decodeDoneFunc(),
+	}
+}
+
+func decodeErrorRecv() *ast.FieldList {
+	return &ast.FieldList{
+		List: []*ast.Field{
+			{
+				Names: []*ast.Ident{
+					ast.NewIdent("e"),
+				},
+				Type: &ast.StarExpr{
+					X: ast.NewIdent("DecodeError"),
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) readErrorFunc() *ast.FuncDecl {
+	clauses := make([]ast.Stmt, 0, 2)
+
+	for _, endian := range [...]string{"LittleEndian", "BigEndian"} {
+		clauses = append(clauses, &ast.CaseClause{
+			List: []ast.Expr{
+				&ast.StarExpr{
+					X: &ast.SelectorExpr{
+						X:   ast.NewIdent("byteio"),
+						Sel: ast.NewIdent("Sticky" + endian + "Reader"),
+					},
+				},
+			},
+			Body: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.SelectorExpr{
+							X:   ast.NewIdent("r"),
+							Sel: ast.NewIdent("Err"),
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_read_error"),
+		Type: &ast.FuncType{
+			Func: c.newLine(),
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("R"),
+						},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent("StickyReader"),
+						},
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("r"),
+						},
+						Type: ast.NewIdent("R"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.TypeSwitchStmt{
+					Assign: &ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("r"),
+						},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.TypeAssertExpr{
+								X: &ast.CallExpr{
+									Fun: ast.NewIdent("any"),
+									Args: []ast.Expr{
+										ast.NewIdent("r"),
+									},
+								},
+							},
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: clauses,
+					},
+				},
+				&ast.ReturnStmt{
+					Return: c.newLine(),
+					Results: []ast.Expr{
+						ast.NewIdent("nil"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func asDecodeError() *ast.AssignStmt {
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("de"),
+			ast.NewIdent("ok"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.TypeAssertExpr{
+				X: ast.NewIdent("err"),
+				Type: &ast.StarExpr{
+					X: ast.NewIdent("DecodeError"),
+				},
+			},
+		},
+	}
+}
+
+func dePath() *ast.SelectorExpr {
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent("de"),
+		Sel: ast.NewIdent("Path"),
+	}
+}
+
+func deErr() *ast.SelectorExpr {
+	return &ast.SelectorExpr{
+		X:   ast.NewIdent("de"),
+		Sel: ast.NewIdent("Err"),
+	}
+}
+
+func (c *constructor) decodeErrorFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_decode_error"),
+		Type: &ast.FuncType{
+			Func: c.newLine(),
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("path"),
+						},
+						Type: ast.NewIdent("string"),
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("err"),
+						},
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IfStmt{
+					Init: asDecodeError(),
+					Cond: ast.NewIdent("ok"),
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.IfStmt{
+								Cond: &ast.BinaryExpr{
+									X: &ast.BinaryExpr{
+										X:  dePath(),
+										Op: token.NEQ,
+										Y: &ast.BasicLit{
+											Kind:  token.STRING,
+											Value: `""`,
+										},
+									},
+									Op: token.LAND,
+									Y: &ast.UnaryExpr{
+										Op: token.NOT,
+										X: &ast.CallExpr{
+											Fun: &ast.SelectorExpr{
+												X:   ast.NewIdent("strings"),
+												Sel: ast.NewIdent("ContainsRune"),
+											},
+											Args: []ast.Expr{
+												&ast.BasicLit{
+													Kind:  token.STRING,
+													Value: `".["`,
+												},
+												&ast.CallExpr{
+													Fun: ast.NewIdent("rune"),
+													Args: []ast.Expr{
+														&ast.IndexExpr{
+															X: dePath(),
+															Index: &ast.BasicLit{
+																Kind:  token.INT,
+																Value: "0",
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+								Body: &ast.BlockStmt{
+									List: []ast.Stmt{
+										&ast.AssignStmt{
+											Lhs: []ast.Expr{
+												ast.NewIdent("path"),
+											},
+											Tok: token.ADD_ASSIGN,
+											Rhs: []ast.Expr{
+												&ast.BasicLit{
+													Kind:  token.STRING,
+													Value: `"."`,
+												},
+											},
+										},
+									},
+								},
+							},
+							&ast.AssignStmt{
+								Lhs: []ast.Expr{
+									&ast.SelectorExpr{
+										X: &ast.Ident{
+											NamePos: c.newLine(),
+											Name:    "de",
+										},
+										Sel: ast.NewIdent("Path"),
+									},
+								},
+								Tok: token.ASSIGN,
+								Rhs: []ast.Expr{
+									&ast.BinaryExpr{
+										X:  ast.NewIdent("path"),
+										Op: token.ADD,
+										Y:  dePath(),
+									},
+								},
+							},
+							&ast.ReturnStmt{
+								Return: c.newLine(),
+								Results: []ast.Expr{
+									ast.NewIdent("de"),
+								},
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Return: c.newLine(),
+					Results: []ast.Expr{
+						&ast.UnaryExpr{
+							Op: token.AND,
+							X: &ast.CompositeLit{
+								Type: ast.NewIdent("DecodeError"),
+								Elts: []ast.Expr{
+									&ast.KeyValueExpr{
+										Key:   ast.NewIdent("Path"),
+										Value: ast.NewIdent("path"),
+									},
+									&ast.KeyValueExpr{
+										Key:   ast.NewIdent("Err"),
+										Value: ast.NewIdent("err"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) decodeDoneFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_decode_done"),
+		Type: &ast.FuncType{
+			Func: c.newLine(),
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("err"),
+						},
+						Type: ast.NewIdent("error"),
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("offset"),
+						},
+						Type: ast.NewIdent("int64"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IfStmt{
+					Init: asDecodeError(),
+					Cond: ast.NewIdent("ok"),
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.IfStmt{
+								Cond: &ast.BinaryExpr{
+									X:  deErr(),
+									Op: token.EQL,
+									Y: &ast.SelectorExpr{
+										X:   ast.NewIdent("io"),
+										Sel: ast.NewIdent("EOF"),
+									},
+								},
+								Body: &ast.BlockStmt{
+									List: []ast.Stmt{
+										&ast.IfStmt{
+											Cond: &ast.BinaryExpr{
+												X:  ast.NewIdent("offset"),
+												Op: token.EQL,
+												Y: &ast.BasicLit{
+													Kind:  token.INT,
+													Value: "0",
+												},
+											},
+											Body: &ast.BlockStmt{
+												List: []ast.Stmt{
+													&ast.ReturnStmt{
+														Results: []ast.Expr{
+															&ast.SelectorExpr{
+																X:   ast.NewIdent("io"),
+																Sel: ast.NewIdent("EOF"),
+															},
+														},
+													},
+												},
+											},
+										},
+										&ast.AssignStmt{
+											Lhs: []ast.Expr{
+												&ast.SelectorExpr{
+													X: &ast.Ident{
+														NamePos: c.newLine(),
+														Name:    "de",
+													},
+													Sel: ast.NewIdent("Err"),
+												},
+											},
+											Tok: token.ASSIGN,
+											Rhs: []ast.Expr{
+												&ast.SelectorExpr{
+													X:   ast.NewIdent("io"),
+													Sel: ast.NewIdent("ErrUnexpectedEOF"),
+												},
+											},
+										},
+									},
+								},
+							},
+							&ast.AssignStmt{
+								Lhs: []ast.Expr{
+									&ast.SelectorExpr{
+										X: &ast.Ident{
+											NamePos: c.newLine(),
+											Name:    "de",
+										},
+										Sel: ast.NewIdent("Path"),
+									},
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("de"),
+										Sel: ast.NewIdent("Offset"),
+									},
+								},
+								Tok: token.ASSIGN,
+								Rhs: []ast.Expr{
+									&ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X:   ast.NewIdent("strings"),
+											Sel: ast.NewIdent("TrimPrefix"),
+										},
+										Args: []ast.Expr{
+											dePath(),
+											&ast.BasicLit{
+												Kind:  token.STRING,
+												Value: `"."`,
+											},
+										},
+									},
+									ast.NewIdent("offset"),
+								},
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Return: c.newLine(),
+					Results: []ast.Expr{
+						ast.NewIdent("err"),
+					},
+				},
+			},
+		},
+	}
+}
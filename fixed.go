@@ -0,0 +1,708 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+func sizeConstName(typ *types.Named) string {
+	return typ.Obj().Name() + "BinarySize"
+}
+
+func intLit(n int64) *ast.BasicLit {
+	return &ast.BasicLit{
+		Kind:  token.INT,
+		Value: strconv.FormatInt(n, 10),
+	}
+}
+
+func (c *constructor) namedConfig(typ *types.Named) config {
+	for t := range c.types {
+		if types.Identical(t, typ) {
+			conf := c.configs[t]
+			conf.bigEndian = c.bigEndian
+
+			return conf
+		}
+	}
+
+	if conf, ok := c.ownConfig(typ); ok {
+		conf.bigEndian = c.bigEndian
+
+		return conf
+	}
+
+	return c.config
+}
+
+func (c *constructor) fixedSize(typ types.Type) (int64, bool) {
+	if named, ok := typ.(*types.Named); ok {
+		if stdlibFunc(named) != "" || binaryFunc(named, true) != "" || named.TypeParams().Len() > 0 {
+			return 0, false
+		}
+
+		conf := c.config
+		c.config = c.namedConfig(named)
+
+		defer func() { c.config = conf }()
+
+		if c.version > 0 || c.tagged || c.omitZero {
+			return 0, false
+		}
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		return c.fixedStructSize(t)
+	case *types.Array:
+		size, ok := c.fixedSize(t.Elem())
+
+		return size * t.Len(), ok
+	case *types.Basic:
+		switch t.Kind() {
+		case types.Complex64:
+			return 8, true
+		case types.Complex128:
+			return 16, true
+		case types.String:
+			return 0, false
+		}
+
+		if method, kind := c.basicMethod(t.Kind()); method != "" && method != "IntX" && method != "UintX" {
+			return sizes.Sizeof(types.Typ[kind]), true
+		}
+	}
+
+	return 0, false
+}
+
+func (c *constructor) fixedStructSize(t *types.Struct) (int64, bool) {
+	fields, err := c.structFields(t)
+	if err != nil {
+		return 0, false
+	}
+
+	conf := c.config
+
+	defer func() { c.config = conf }()
+
+	var total int64
+
+	for _, field := range fields {
+		if c.config, err = conf.field(field); err != nil {
+			return 0, false
+		} else if c.skip && !c.supported(field.Type(), nil) {
+			continue
+		} else if field.since > 0 || field.removed > 0 {
+			return 0, false
+		}
+
+		size, ok := c.fixedSize(field.Type())
+		if !ok {
+			return 0, false
+		}
+
+		total += size
+	}
+
+	return total, true
+}
+
+func (c *constructor) sizeConst(typ *types.Named, size int64) *ast.GenDecl {
+	name := sizeConstName(typ)
+
+	return &ast.GenDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Slash: c.newLine(),
+					Text:  "// " + name + " is the number of bytes in the binary encoding of " + typ.Obj().Name() + ".",
+				},
+			},
+		},
+		Tok: token.CONST,
+		Specs: []ast.Spec{
+			&ast.ValueSpec{
+				Names: []*ast.Ident{
+					ast.NewIdent(name),
+				},
+				Values: []ast.Expr{
+					intLit(size),
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) assignFixed(typ *types.Named, typeName ast.Expr, funcName string) *ast.FuncDecl {
+	c.use("slices")
+
+	size := ast.NewIdent(sizeConstName(typ))
+	decl := c.assignBinary(typeName, funcName, "")
+	decl.Body.List = []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("l"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: ast.NewIdent("len"),
+					Args: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("b"),
+			},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.SliceExpr{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("slices"),
+							Sel: ast.NewIdent("Grow"),
+						},
+						Args: []ast.Expr{
+							ast.NewIdent("b"),
+							size,
+						},
+					},
+					High: &ast.BinaryExpr{
+						X:  ast.NewIdent("l"),
+						Op: token.ADD,
+						Y:  size,
+					},
+				},
+			},
+		},
+		fixedBuffer(ast.NewIdent("e"), size, &ast.SliceExpr{
+			X:   ast.NewIdent("b"),
+			Low: ast.NewIdent("l"),
+		}),
+	}
+
+	c.statements = nil
+
+	c.fixedType(&ast.StarExpr{X: ast.NewIdent("t")}, typ, ast.NewIdent("e"), 0, true)
+
+	decl.Body.List = append(append(decl.Body.List, c.statements...), &ast.ReturnStmt{
+		Return: c.newLine(),
+		Results: []ast.Expr{
+			ast.NewIdent("b"),
+			ast.NewIdent("nil"),
+		},
+	})
+
+	return decl
+}
+
+func (c *constructor) unmarshalFixed(typ *types.Named, typeName ast.Expr, funcName string) *ast.FuncDecl {
+	size := ast.NewIdent(sizeConstName(typ))
+	decl := c.unmarshalBinary(typeName, funcName, "")
+	decl.Body.List = []ast.Stmt{
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.CallExpr{
+					Fun: ast.NewIdent("len"),
+					Args: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+				},
+				Op: token.LSS,
+				Y:  size,
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					c.fixedShort(size),
+				},
+			},
+			Else: c.fixedTrailing(size),
+		},
+		fixedBuffer(&ast.Ident{
+			NamePos: c.newLine(),
+			Name:    "e",
+		}, size, ast.NewIdent("b")),
+	}
+
+	c.statements = nil
+
+	c.fixedType(&ast.StarExpr{X: ast.NewIdent("t")}, typ, ast.NewIdent("e"), 0, false)
+
+	decl.Body.List = append(append(decl.Body.List, c.statements...), &ast.ReturnStmt{
+		Return: c.newLine(),
+		Results: []ast.Expr{
+			ast.NewIdent("nil"),
+		},
+	})
+
+	return decl
+}
+
+func (c *constructor) fixedShort(size ast.Expr) ast.Stmt {
+	if c.strict {
+		c.use("io")
+
+		return &ast.ReturnStmt{
+			Results: []ast.Expr{
+				&ast.SelectorExpr{
+					X:   ast.NewIdent("io"),
+					Sel: ast.NewIdent("ErrUnexpectedEOF"),
+				},
+			},
+		}
+	}
+
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("b"),
+		},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{
+			&ast.SliceExpr{
+				X: &ast.CallExpr{
+					Fun: ast.NewIdent("append"),
+					Args: []ast.Expr{
+						&ast.CallExpr{
+							Fun: ast.NewIdent("make"),
+							Args: []ast.Expr{
+								&ast.ArrayType{
+									Elt: ast.NewIdent("byte"),
+								},
+								intLit(0),
+								size,
+							},
+						},
+						ast.NewIdent("b"),
+					},
+					Ellipsis: 1,
+				},
+				High: size,
+			},
+		},
+	}
+}
+
+func (c *constructor) fixedTrailing(size ast.Expr) ast.Stmt {
+	if !c.strict {
+		return nil
+	}
+
+	excess := &ast.BinaryExpr{
+		X: &ast.CallExpr{
+			Fun: ast.NewIdent("len"),
+			Args: []ast.Expr{
+				ast.NewIdent("b"),
+			},
+		},
+		Op: token.SUB,
+		Y:  size,
+	}
+
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X: &ast.CallExpr{
+				Fun: ast.NewIdent("len"),
+				Args: []ast.Expr{
+					ast.NewIdent("b"),
+				},
+			},
+			Op: token.GTR,
+			Y:  size,
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				c.trailingBytes(excess),
+			},
+		},
+	}
+}
+
+func (c *constructor) sizeFixed(typ *types.Named, typeName ast.Expr, funcName string) *ast.FuncDecl {
+	return c.binarySize(typeName, funcName, ast.NewIdent(sizeConstName(typ)))
+}
+
+func fixedBuffer(buf, size ast.Expr, from ast.Expr) *ast.AssignStmt {
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{buf},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.ParenExpr{
+					X: &ast.StarExpr{
+						X: &ast.ArrayType{
+							Len: size,
+							Elt: ast.NewIdent("byte"),
+						},
+					},
+				},
+				Args: []ast.Expr{from},
+			},
+		},
+	}
+}
+
+func (c *constructor) fixedType(name ast.Expr, typ types.Type, buf *ast.Ident, offset int64, marshal bool) {
+	if named, ok := typ.(*types.Named); ok {
+		conf := c.config
+		c.config = c.namedConfig(named)
+
+		defer func() { c.config = conf }()
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		c.fixedStruct(name, t, buf, offset, marshal)
+	case *types.Array:
+		c.fixedArray(name, t, buf, offset, marshal)
+	case *types.Basic:
+		c.fixedBasic(name, typ, t, buf, offset, marshal)
+	}
+}
+
+func (c *constructor) fixedStruct(name ast.Expr, t *types.Struct, buf *ast.Ident, offset int64, marshal bool) {
+	if star, ok := name.(*ast.StarExpr); ok {
+		name = star.X
+	}
+
+	fields, _ := c.structFields(t)
+	conf := c.config
+
+	for _, field := range fields {
+		if c.config, _ = conf.field(field); c.skip && !c.supported(field.Type(), nil) {
+			continue
+		}
+
+		c.fixedType(&ast.SelectorExpr{
+			X:   name,
+			Sel: ast.NewIdent(field.Name()),
+		}, field.Type(), buf, offset, marshal)
+
+		size, _ := c.fixedSize(field.Type())
+		offset += size
+	}
+
+	c.config = conf
+}
+
+func (c *constructor) fixedArray(name ast.Expr, t *types.Array, buf *ast.Ident, offset int64, marshal bool) {
+	size, _ := c.fixedSize(t.Elem())
+
+	if size == 0 {
+		return
+	} else if types.Identical(t.Elem(), types.Typ[types.Uint8]) {
+		dst, src := ast.Expr(&ast.SliceExpr{
+			X:    buf,
+			Low:  intLit(offset),
+			High: intLit(offset + t.Len()),
+		}), ast.Expr(&ast.SliceExpr{X: name})
+
+		if !marshal {
+			dst, src = src, dst
+		}
+
+		c.addStatement(&ast.ExprStmt{
+			X: &ast.CallExpr{
+				Fun:  ast.NewIdent("copy"),
+				Args: []ast.Expr{dst, src},
+			},
+		})
+
+		return
+	}
+
+	d := c.subConstructor()
+	n := c.varName("n")
+	e := d.varName("e")
+
+	var start ast.Expr = &ast.BinaryExpr{
+		X:  n,
+		Op: token.MUL,
+		Y:  intLit(size),
+	}
+
+	if offset != 0 {
+		start = &ast.BinaryExpr{
+			X:  intLit(offset),
+			Op: token.ADD,
+			Y:  start,
+		}
+	}
+
+	d.addStatement(fixedBuffer(e, intLit(size), &ast.SliceExpr{
+		X:   buf,
+		Low: start,
+	}))
+	d.fixedType(&ast.IndexExpr{
+		X:     name,
+		Index: n,
+	}, t.Elem(), e, 0, marshal)
+	c.addStatement(&ast.RangeStmt{
+		For: c.newLine(),
+		Key: n,
+		Tok: token.DEFINE,
+		X:   name,
+		Body: &ast.BlockStmt{
+			List: d.statements,
+		},
+	})
+}
+
+func (c *constructor) fixedBasic(name ast.Expr, typ types.Type, t *types.Basic, buf *ast.Ident, offset int64, marshal bool) {
+	switch t.Kind() {
+	case types.Complex64, types.Complex128:
+		part := types.Typ[types.Float32]
+
+		if t.Kind() == types.Complex128 {
+			part = types.Typ[types.Float64]
+		}
+
+		size := sizes.Sizeof(part)
+
+		if marshal {
+			c.putFixed(&ast.CallExpr{
+				Fun:  ast.NewIdent("real"),
+				Args: []ast.Expr{name},
+			}, part, part.Kind(), buf, offset)
+			c.putFixed(&ast.CallExpr{
+				Fun:  ast.NewIdent("imag"),
+				Args: []ast.Expr{name},
+			}, part, part.Kind(), buf, offset+size)
+		} else {
+			re, _ := c.getFixed(part.Kind(), buf, offset)
+			im, _ := c.getFixed(part.Kind(), buf, offset+size)
+
+			c.addStatement(&ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{
+					c.convertTo(&ast.CallExpr{
+						Fun:  ast.NewIdent("complex"),
+						Args: []ast.Expr{re, im},
+					}, typ, t.Kind()),
+				},
+			})
+		}
+
+		return
+	}
+
+	_, kind := c.basicMethod(t.Kind())
+
+	if marshal {
+		c.putFixed(name, typ, kind, buf, offset)
+	} else {
+		value, kind := c.getFixed(kind, buf, offset)
+
+		c.addStatement(&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				c.convertTo(value, typ, kind),
+			},
+		})
+	}
+}
+
+func (c *constructor) byteOrder(method string) ast.Expr {
+	c.use("encoding/binary")
+
+	return &ast.SelectorExpr{
+		X: &ast.SelectorExpr{
+			X:   ast.NewIdent("binary"),
+			Sel: ast.NewIdent(c.endian()),
+		},
+		Sel: ast.NewIdent(method),
+	}
+}
+
+func fixedBits(kind types.BasicKind) (types.BasicKind, string) {
+	switch kind {
+	case types.Int16:
+		return types.Uint16, ""
+	case types.Int32:
+		return types.Uint32, ""
+	case types.Int64:
+		return types.Uint64, ""
+	case types.Float32:
+		return types.Uint32, "Float32"
+	case types.Float64:
+		return types.Uint64, "Float64"
+	}
+
+	return kind, ""
+}
+
+func bitsMethod(kind types.BasicKind) string {
+	name := types.Typ[kind].Name()
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func (c *constructor) putFixed(value ast.Expr, typ types.Type, kind types.BasicKind, buf *ast.Ident, offset int64) {
+	switch kind {
+	case types.Bool:
+		c.helpers["_fixed_bool"] = true
+
+		value = &ast.CallExpr{
+			Fun:  ast.NewIdent("_fixed_bool"),
+			Args: []ast.Expr{value},
+		}
+	case types.Int8, types.Uint8:
+		value = convert(value, typ, types.Uint8)
+	}
+
+	bits, float := fixedBits(kind)
+
+	switch {
+	case bits == types.Bool || bits == types.Int8 || bits == types.Uint8:
+		c.addStatement(&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				&ast.IndexExpr{
+					X:     buf,
+					Index: intLit(offset),
+				},
+			},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{value},
+		})
+
+		return
+	case float != "":
+		c.use("math")
+
+		value = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("math"),
+				Sel: ast.NewIdent(float + "bits"),
+			},
+			Args: []ast.Expr{
+				convert(value, typ, kind),
+			},
+		}
+	default:
+		value = convert(value, typ, bits)
+	}
+
+	c.addStatement(&ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: c.byteOrder("Put" + bitsMethod(bits)),
+			Args: []ast.Expr{
+				&ast.SliceExpr{
+					X:    buf,
+					Low:  intLit(offset),
+					High: intLit(offset + sizes.Sizeof(types.Typ[bits])),
+				},
+				value,
+			},
+		},
+	})
+}
+
+func (c *constructor) getFixed(kind types.BasicKind, buf *ast.Ident, offset int64) (ast.Expr, types.BasicKind) {
+	b := &ast.IndexExpr{
+		X:     buf,
+		Index: intLit(offset),
+	}
+
+	switch kind {
+	case types.Bool:
+		return &ast.BinaryExpr{
+			X:  b,
+			Op: token.NEQ,
+			Y:  intLit(0),
+		}, types.Bool
+	case types.Int8, types.Uint8:
+		return b, types.Uint8
+	}
+
+	bits, float := fixedBits(kind)
+
+	var value ast.Expr = &ast.CallExpr{
+		Fun: c.byteOrder(bitsMethod(bits)),
+		Args: []ast.Expr{
+			&ast.SliceExpr{
+				X:    buf,
+				Low:  intLit(offset),
+				High: intLit(offset + sizes.Sizeof(types.Typ[bits])),
+			},
+		},
+	}
+
+	if float != "" {
+		c.use("math")
+
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("math"),
+				Sel: ast.NewIdent(float + "frombits"),
+			},
+			Args: []ast.Expr{value},
+		}, kind
+	}
+
+	return value, bits
+}
+
+func fixedBool() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_fixed_bool"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("T")},
+						Type: &ast.UnaryExpr{
+							Op: token.TILDE,
+							X:  ast.NewIdent("bool"),
+						},
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("b"),
+						},
+						Type: ast.NewIdent("T"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("byte"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IfStmt{
+					Cond: ast.NewIdent("b"),
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ReturnStmt{
+								Results: []ast.Expr{
+									intLit(1),
+								},
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						intLit(0),
+					},
+				},
+			},
+		},
+	}
+}
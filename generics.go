@@ -0,0 +1,431 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var orderedConstraint = types.NewInterfaceType(nil, []types.Type{
+	types.NewUnion([]*types.Term{
+		types.NewTerm(true, types.Typ[types.Int]),
+		types.NewTerm(true, types.Typ[types.Int8]),
+		types.NewTerm(true, types.Typ[types.Int16]),
+		types.NewTerm(true, types.Typ[types.Int32]),
+		types.NewTerm(true, types.Typ[types.Int64]),
+		types.NewTerm(true, types.Typ[types.Uint]),
+		types.NewTerm(true, types.Typ[types.Uint8]),
+		types.NewTerm(true, types.Typ[types.Uint16]),
+		types.NewTerm(true, types.Typ[types.Uint32]),
+		types.NewTerm(true, types.Typ[types.Uint64]),
+		types.NewTerm(true, types.Typ[types.Uintptr]),
+		types.NewTerm(true, types.Typ[types.Float32]),
+		types.NewTerm(true, types.Typ[types.Float64]),
+		types.NewTerm(true, types.Typ[types.String]),
+	}),
+}).Complete()
+
+var basicTypes = []types.Type{
+	types.Typ[types.Bool],
+	types.Typ[types.Int],
+	types.Typ[types.Int8],
+	types.Typ[types.Int16],
+	types.Typ[types.Int32],
+	types.Typ[types.Int64],
+	types.Typ[types.Uint],
+	types.Typ[types.Uint8],
+	types.Typ[types.Uint16],
+	types.Typ[types.Uint32],
+	types.Typ[types.Uint64],
+	types.Typ[types.Uintptr],
+	types.Typ[types.Float32],
+	types.Typ[types.Float64],
+	types.Typ[types.Complex64],
+	types.Typ[types.Complex128],
+	types.Typ[types.String],
+}
+
+func funcSuffix(typ *types.Named) string {
+	name := typ.Obj().Name()
+
+	if typ.TypeArgs().Len() > 0 {
+		name = strings.TrimSuffix(types.TypeString(typ, types.RelativeTo(typ.Obj().Pkg())), "]")
+	}
+
+	var sb strings.Builder
+
+	for _, r := range name {
+		switch {
+		case r == '_':
+			sb.WriteString("__")
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	return sb.String()
+}
+
+func hasTypeParams(typ *types.Named) bool {
+	for arg := range typ.TypeArgs().Types() {
+		switch t := arg.(type) {
+		case *types.TypeParam:
+			return true
+		case *types.Named:
+			if hasTypeParams(t) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func typeArgs(typ *types.Named) []types.Type {
+	var args []types.Type
+
+	if typ.TypeArgs().Len() > 0 {
+		args = slices.Collect(typ.TypeArgs().Types())
+	} else {
+		for tp := range typ.TypeParams().TypeParams() {
+			args = append(args, tp)
+		}
+	}
+
+	return args
+}
+
+func (c *constructor) typeParams(typ *types.Named, stream string) ([]*ast.Field, string) {
+	var fields []*ast.Field
+
+	names := make(map[string]bool)
+
+	if typ.TypeArgs().Len() > 0 {
+		return nil, stream
+	}
+
+	for tp := range typ.TypeParams().TypeParams() {
+		names[tp.Obj().Name()] = true
+
+		fields = append(fields, &ast.Field{
+			Names: []*ast.Ident{
+				ast.NewIdent(tp.Obj().Name()),
+			},
+			Type: c.typeExpr(tp.Constraint()),
+		})
+	}
+
+	for names[stream] {
+		stream += "_"
+	}
+
+	return fields, stream
+}
+
+func (c *constructor) typeExpr(typ types.Type) ast.Expr {
+	expr, err := parser.ParseExpr(types.TypeString(typ, func(pkg *types.Package) string {
+		if pkg == c.pkg {
+			return ""
+		}
+
+		c.usePackage(pkg)
+
+		return pkg.Name()
+	}))
+	if err != nil {
+		c.setError(err)
+
+		return nil
+	}
+
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if v := reflect.ValueOf(n); v.Kind() == reflect.Pointer && !v.IsNil() {
+			v = v.Elem()
+
+			for i := range v.NumField() {
+				if f := v.Field(i); f.Type() == reflect.TypeFor[token.Pos]() {
+					f.SetInt(0)
+				}
+			}
+		}
+
+		return true
+	})
+
+	return expr
+}
+
+func (c *constructor) callParam(marshal bool, name ast.Expr, tp *types.TypeParam) {
+	terms := c.paramTerms(tp)
+	if len(terms) == 0 {
+		c.callAny(marshal, name)
+
+		return
+	}
+
+	x := c.varName("x")
+	clauses := make([]ast.Stmt, 0, len(terms)+1)
+
+	for _, term := range terms {
+		d := c.subConstructor()
+
+		if marshal {
+			d.writeType(&ast.StarExpr{X: x}, term)
+		} else {
+			d.readType(&ast.StarExpr{X: x}, term)
+
+			c.unchecked = c.unchecked || d.unchecked
+		}
+
+		clauses = append(clauses, &ast.CaseClause{
+			List: []ast.Expr{
+				&ast.StarExpr{
+					X: c.typeExpr(term),
+				},
+			},
+			Body: d.statements,
+		})
+	}
+
+	d := c.subConstructor()
+
+	d.callAny(marshal, &ast.StarExpr{X: x})
+
+	clauses = append(clauses, &ast.CaseClause{
+		Body: d.statements,
+	})
+
+	c.addStatement(&ast.TypeSwitchStmt{
+		Switch: c.newLine(),
+		Assign: &ast.AssignStmt{
+			Lhs: []ast.Expr{x},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.TypeAssertExpr{
+					X: &ast.CallExpr{
+						Fun: ast.NewIdent("any"),
+						Args: []ast.Expr{
+							addr(name),
+						},
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: clauses,
+		},
+	})
+}
+
+func (c *constructor) callAny(marshal bool, name ast.Expr) {
+	if marshal {
+		c.helpers["_marshal_binary"] = true
+		c.helpers["_marshal_appender"] = true
+
+		c.callHelper("_marshal_any", "w", name)
+	} else {
+		c.helpers["_unmarshal_binary"] = true
+
+		c.callHelper("_unmarshal_any", "r", name)
+	}
+}
+
+func (c *constructor) paramTerms(tp *types.TypeParam) []types.Type {
+	var terms []types.Type
+
+	candidates := constraintTerms(tp.Constraint())
+	if iface, ok := tp.Constraint().Underlying().(*types.Interface); ok && len(candidates) == 0 && iface.NumMethods() == 0 {
+		candidates = basicTypes
+	}
+
+	for _, term := range candidates {
+		if _, ok := term.Underlying().(*types.Interface); ok || !c.supported(term, nil) {
+			continue
+		} else if !slices.ContainsFunc(terms, func(t types.Type) bool { return types.Identical(t, term) }) {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+func constraintTerms(typ types.Type) []types.Type {
+	iface, ok := typ.Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+
+	var terms []types.Type
+
+	for embedded := range iface.EmbeddedTypes() {
+		if union, ok := embedded.(*types.Union); ok {
+			for term := range union.Terms() {
+				terms = append(terms, constraintTerms(term.Type())...)
+
+				if _, ok := term.Type().Underlying().(*types.Interface); !ok {
+					terms = append(terms, term.Type())
+				}
+			}
+		} else if _, ok := embedded.Underlying().(*types.Interface); ok {
+			terms = append(terms, constraintTerms(embedded)...)
+		} else {
+			terms = append(terms, embedded)
+		}
+	}
+
+	return terms
+}
+
+func (c *constructor) marshalAny() *ast.FuncDecl {
+	return c.anyFunc("_marshal_any", "W", "StickyWriter", "w", []ast.Stmt{
+		&ast.TypeSwitchStmt{
+			Assign: &ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("t"),
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.TypeAssertExpr{
+						X: ast.NewIdent("t"),
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					anyCase("BinaryMarshaler", "_marshal_binary", c.refsArgs(ast.NewIdent("t"), ast.NewIdent("w"))...),
+					anyCase("BinaryAppender", "_marshal_appender", c.refsArgs(ast.NewIdent("t"), ast.NewIdent("w"))...),
+				},
+			},
+		},
+	})
+}
+
+func (c *constructor) unmarshalAny() *ast.FuncDecl {
+	return c.anyFunc("_unmarshal_any", "R", "StickyReader", "r", []ast.Stmt{
+		&ast.TypeSwitchStmt{
+			Assign: &ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("t"),
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.TypeAssertExpr{
+						X: ast.NewIdent("t"),
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					anyCase("BinaryUnmarshaler", "_unmarshal_binary", c.refsArgs(c.limitArgs(ast.NewIdent("t"), ast.NewIdent("r"))...)...),
+				},
+			},
+		},
+	})
+}
+
+func anyCase(iface, funcName string, args ...ast.Expr) *ast.CaseClause {
+	return &ast.CaseClause{
+		List: []ast.Expr{
+			&ast.SelectorExpr{
+				X:   ast.NewIdent("encoding"),
+				Sel: ast.NewIdent(iface),
+			},
+		},
+		Body: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.CallExpr{
+						Fun:  ast.NewIdent(funcName),
+						Args: args,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) anyFunc(name, typeParam, constraint, stream string, body []ast.Stmt) *ast.FuncDecl {
+	c.use("encoding")
+	c.use("errors")
+	c.use("fmt")
+
+	params := []*ast.Field{
+		{
+			Names: []*ast.Ident{
+				ast.NewIdent("t"),
+			},
+			Type: ast.NewIdent("any"),
+		},
+		{
+			Names: []*ast.Ident{
+				ast.NewIdent(stream),
+			},
+			Type: ast.NewIdent(typeParam),
+		},
+	}
+
+	if stream == "r" {
+		params = c.limitParam(params)
+	}
+
+	params = c.refsParam(params)
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(name),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent(typeParam),
+						},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent(constraint),
+						},
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: params,
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: append(body, &ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("fmt"),
+							Sel: ast.NewIdent("Errorf"),
+						},
+						Args: []ast.Expr{
+							&ast.BasicLit{
+								Kind:  token.STRING,
+								Value: `"%w: %T"`,
+							},
+							&ast.SelectorExpr{
+								X:   ast.NewIdent("errors"),
+								Sel: ast.NewIdent("ErrUnsupported"),
+							},
+							ast.NewIdent("t"),
+						},
+					},
+				},
+			}),
+		},
+	}
+}
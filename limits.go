@@ -0,0 +1,682 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+type limits struct {
+	slice, mapSize, str, alloc, depth uint64
+}
+
+func (l limits) enabled() bool {
+	return l != limits{}
+}
+
+var sizes = types.SizesFor("gc", "amd64")
+
+func sizeof(typ types.Type) uint64 {
+	if hasTypeParam(typ) {
+		return 1
+	}
+
+	return uint64(sizes.Sizeof(typ))
+}
+
+func hasTypeParam(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		return hasTypeParam(t.Underlying())
+	case *types.Array:
+		return hasTypeParam(t.Elem())
+	case *types.Struct:
+		for field := range t.Fields() {
+			if hasTypeParam(field.Type()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func limitExpr(limit uint64) ast.Expr {
+	if limit == 0 {
+		return &ast.SelectorExpr{
+			X:   ast.NewIdent("math"),
+			Sel: ast.NewIdent("MaxUint64"),
+		}
+	}
+
+	return &ast.BasicLit{
+		Kind:  token.INT,
+		Value: strconv.FormatUint(limit, 10),
+	}
+}
+
+func (c *constructor) limitArgs(args ...ast.Expr) []ast.Expr {
+	if !c.limits.enabled() {
+		return args
+	}
+
+	return append(args, ast.NewIdent("l"))
+}
+
+func (c *constructor) newLimitArgs(args ...ast.Expr) []ast.Expr {
+	if !c.limits.enabled() {
+		return args
+	}
+
+	return append(args, &ast.UnaryExpr{
+		Op: token.AND,
+		X: &ast.CompositeLit{
+			Type: ast.NewIdent("_limits"),
+		},
+	})
+}
+
+func (c *constructor) limitParam(params []*ast.Field) []*ast.Field {
+	if !c.limits.enabled() {
+		return params
+	}
+
+	return append(params, &ast.Field{
+		Names: []*ast.Ident{
+			ast.NewIdent("l"),
+		},
+		Type: &ast.StarExpr{
+			X: ast.NewIdent("_limits"),
+		},
+	})
+}
+
+func (c *constructor) checkLength(limit string, max, size uint64, length ast.Expr, kind types.BasicKind) (ast.Expr, types.BasicKind) {
+	if !c.limits.enabled() {
+		return length, kind
+	}
+
+	if max == 0 {
+		c.use("math")
+	}
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("l"),
+			Sel: ast.NewIdent("length"),
+		},
+		Args: []ast.Expr{
+			&ast.BasicLit{
+				Kind:  token.STRING,
+				Value: strconv.Quote(limit),
+			},
+			convert(length, types.Typ[kind], types.Uint64),
+			limitExpr(max),
+			&ast.BasicLit{
+				Kind:  token.INT,
+				Value: strconv.FormatUint(size, 10),
+			},
+		},
+	}, types.Uint64
+}
+
+func (c *constructor) returnLimits() *ast.ReturnStmt {
+	if !c.limits.enabled() {
+		return returnNil()
+	}
+
+	return &ast.ReturnStmt{
+		Results: []ast.Expr{
+			&ast.SelectorExpr{
+				X:   ast.NewIdent("l"),
+				Sel: ast.NewIdent("err"),
+			},
+		},
+	}
+}
+
+func (c *constructor) readString(name ast.Expr, typ types.Type) {
+	c.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{name},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{
+			c.convertTo(c.stringExpr(), typ, types.String),
+		},
+	})
+}
+
+func (c *constructor) stringExpr() ast.Expr {
+	if !c.limits.enabled() && !c.zeroCopy {
+		return readCall("Read" + c.stringMethod())
+	}
+
+	method, kind := c.length()
+	length, kind := c.checkLength("string length", c.limits.str, 1, &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("r"),
+			Sel: ast.NewIdent("Read" + method),
+		},
+	}, kind)
+
+	if c.zeroCopy {
+		return c.aliasCall("_alias_string", length, kind)
+	}
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("r"),
+			Sel: ast.NewIdent("ReadString"),
+		},
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  ast.NewIdent("int"),
+				Args: []ast.Expr{length},
+			},
+		},
+	}
+}
+
+func (c *constructor) enterDepth() []ast.Stmt {
+	if c.limits.depth == 0 {
+		return nil
+	}
+
+	return []ast.Stmt{
+		&ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("err"),
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("l"),
+							Sel: ast.NewIdent("enter"),
+						},
+					},
+				},
+			},
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent("err"),
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							ast.NewIdent("err"),
+						},
+					},
+				},
+			},
+		},
+		&ast.DeferStmt{
+			Call: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("l"),
+					Sel: ast.NewIdent("leave"),
+				},
+			},
+		},
+	}
+}
+
+func limitError(limit, size, max ast.Expr) ast.Expr {
+	return &ast.UnaryExpr{
+		Op: token.AND,
+		X: &ast.CompositeLit{
+			Type: ast.NewIdent("LimitError"),
+			Elts: []ast.Expr{
+				&ast.KeyValueExpr{
+					Key:   ast.NewIdent("Limit"),
+					Value: limit,
+				},
+				&ast.KeyValueExpr{
+					Key:   ast.NewIdent("Size"),
+					Value: size,
+				},
+				&ast.KeyValueExpr{
+					Key:   ast.NewIdent("Max"),
+					Value: max,
+				},
+			},
+		},
+	}
+}
+
+func setLimitError(err ast.Expr) []ast.Stmt {
+	return []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				&ast.SelectorExpr{
+					X:   ast.NewIdent("l"),
+					Sel: ast.NewIdent("err"),
+				},
+			},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{err},
+		},
+		&ast.ReturnStmt{
+			Results: []ast.Expr{
+				&ast.BasicLit{
+					Kind:  token.INT,
+					Value: "0",
+				},
+			},
+		},
+	}
+}
+
+func limitsRecv() *ast.FieldList {
+	return &ast.FieldList{
+		List: []*ast.Field{
+			{
+				Names: []*ast.Ident{
+					ast.NewIdent("l"),
+				},
+				Type: &ast.StarExpr{
+					X: ast.NewIdent("_limits"),
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) limitDecls() []ast.Decl {
+	if !c.limits.enabled() {
+		return nil
+	}
+
+	c.use("fmt")
+
+	decls := []ast.Decl{
+		&ast.GenDecl{
+			Doc: &ast.CommentGroup{
+				List: []*ast.Comment{
+					{
+						Slash: c.newLine(),
+						Text:  "// LimitError is returned when decoding data that exceeds one of the configured\n// limits. Size is the requested size and Max is the limit that it exceeded.",
+					},
+				},
+			},
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: ast.NewIdent("LimitError"),
+					Type: &ast.StructType{
+						Fields: &ast.FieldList{
+							List: []*ast.Field{
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("Limit"),
+									},
+									Type: ast.NewIdent("string"),
+								},
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("Size"),
+										ast.NewIdent("Max"),
+									},
+									Type: ast.NewIdent("uint64"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.FuncDecl{
+			Recv: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("e"),
+						},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("LimitError"),
+						},
+					},
+				},
+			},
+			Name: ast.NewIdent("Error"),
+			Type: &ast.FuncType{
+				Func:   c.newLine(),
+				Params: &ast.FieldList{},
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Type: ast.NewIdent("string"),
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   ast.NewIdent("fmt"),
+									Sel: ast.NewIdent("Sprintf"),
+								},
+								Args: []ast.Expr{
+									&ast.BasicLit{
+										Kind:  token.STRING,
+										Value: `"%s of %d exceeds limit of %d"`,
+									},
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("e"),
+										Sel: ast.NewIdent("Limit"),
+									},
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("e"),
+										Sel: ast.NewIdent("Size"),
+									},
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("e"),
+										Sel: ast.NewIdent("Max"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.GenDecl{
+			TokPos: c.newLine(),
+			Tok:    token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: ast.NewIdent("_limits"),
+					Type: &ast.StructType{
+						Fields: &ast.FieldList{
+							List: []*ast.Field{
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("alloc"),
+										ast.NewIdent("depth"),
+									},
+									Type: ast.NewIdent("uint64"),
+								},
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("err"),
+									},
+									Type: ast.NewIdent("error"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		c.lengthFunc(),
+	}
+
+	if c.limits.depth != 0 {
+		decls = append(decls, c.enterFunc(), c.leaveFunc())
+	}
+
+	return decls
+}
+
+func (c *constructor) lengthFunc() *ast.FuncDecl {
+	body := []ast.Stmt{
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.SelectorExpr{
+					X:   ast.NewIdent("l"),
+					Sel: ast.NewIdent("err"),
+				},
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							&ast.BasicLit{
+								Kind:  token.INT,
+								Value: "0",
+							},
+						},
+					},
+				},
+			},
+			Else: &ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X:  ast.NewIdent("n"),
+					Op: token.GTR,
+					Y:  ast.NewIdent("max"),
+				},
+				Body: &ast.BlockStmt{
+					List: setLimitError(limitError(ast.NewIdent("limit"), ast.NewIdent("n"), ast.NewIdent("max"))),
+				},
+			},
+		},
+	}
+
+	if c.limits.alloc != 0 {
+		c.use("math")
+		c.use("math/bits")
+
+		body = append(body,
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("hi"),
+					ast.NewIdent("lo"),
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("bits"),
+							Sel: ast.NewIdent("Mul64"),
+						},
+						Args: []ast.Expr{
+							ast.NewIdent("n"),
+							ast.NewIdent("size"),
+						},
+					},
+				},
+			},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X:  ast.NewIdent("hi"),
+					Op: token.NEQ,
+					Y: &ast.BasicLit{
+						Kind:  token.INT,
+						Value: "0",
+					},
+				},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.AssignStmt{
+							Lhs: []ast.Expr{
+								ast.NewIdent("lo"),
+							},
+							Tok: token.ASSIGN,
+							Rhs: []ast.Expr{
+								&ast.SelectorExpr{
+									X:   ast.NewIdent("math"),
+									Sel: ast.NewIdent("MaxUint64"),
+								},
+							},
+						},
+					},
+				},
+			},
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X:  ast.NewIdent("lo"),
+					Op: token.GTR,
+					Y: &ast.BinaryExpr{
+						X:  limitExpr(c.limits.alloc),
+						Op: token.SUB,
+						Y: &ast.SelectorExpr{
+							X:   ast.NewIdent("l"),
+							Sel: ast.NewIdent("alloc"),
+						},
+					},
+				},
+				Body: &ast.BlockStmt{
+					List: setLimitError(limitError(&ast.BasicLit{
+						Kind:  token.STRING,
+						Value: `"total allocation"`,
+					}, ast.NewIdent("lo"), limitExpr(c.limits.alloc))),
+				},
+			},
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{
+					&ast.SelectorExpr{
+						X:   ast.NewIdent("l"),
+						Sel: ast.NewIdent("alloc"),
+					},
+				},
+				Tok: token.ADD_ASSIGN,
+				Rhs: []ast.Expr{
+					ast.NewIdent("lo"),
+				},
+			},
+		)
+	}
+
+	return &ast.FuncDecl{
+		Recv: limitsRecv(),
+		Name: ast.NewIdent("length"),
+		Type: &ast.FuncType{
+			Func: c.newLine(),
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("limit"),
+						},
+						Type: ast.NewIdent("string"),
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("n"),
+							ast.NewIdent("max"),
+							ast.NewIdent("size"),
+						},
+						Type: ast.NewIdent("uint64"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("uint64"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: append(body, &ast.ReturnStmt{
+				Results: []ast.Expr{
+					ast.NewIdent("n"),
+				},
+			}),
+		},
+	}
+}
+
+func (c *constructor) enterFunc() *ast.FuncDecl {
+	depth := &ast.SelectorExpr{
+		X:   ast.NewIdent("l"),
+		Sel: ast.NewIdent("depth"),
+	}
+	limitErr := &ast.SelectorExpr{
+		X:   ast.NewIdent("l"),
+		Sel: ast.NewIdent("err"),
+	}
+
+	return &ast.FuncDecl{
+		Recv: limitsRecv(),
+		Name: ast.NewIdent("enter"),
+		Type: &ast.FuncType{
+			Func:   c.newLine(),
+			Params: &ast.FieldList{},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IncDecStmt{
+					X:   depth,
+					Tok: token.INC,
+				},
+				&ast.IfStmt{
+					Cond: &ast.BinaryExpr{
+						X:  limitErr,
+						Op: token.NEQ,
+						Y:  ast.NewIdent("nil"),
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ReturnStmt{
+								Results: []ast.Expr{
+									limitErr,
+								},
+							},
+						},
+					},
+					Else: &ast.IfStmt{
+						Cond: &ast.BinaryExpr{
+							X:  depth,
+							Op: token.GTR,
+							Y:  limitExpr(c.limits.depth),
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.ReturnStmt{
+									Results: []ast.Expr{
+										limitError(&ast.BasicLit{
+											Kind:  token.STRING,
+											Value: `"nesting depth"`,
+										}, depth, limitExpr(c.limits.depth)),
+									},
+								},
+							},
+						},
+					},
+				},
+				returnNil(),
+			},
+		},
+	}
+}
+
+func (c *constructor) leaveFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Recv: limitsRecv(),
+		Name: ast.NewIdent("leave"),
+		Type: &ast.FuncType{
+			Func:   c.newLine(),
+			Params: &ast.FieldList{},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IncDecStmt{
+					X: &ast.SelectorExpr{
+						X:   ast.NewIdent("l"),
+						Sel: ast.NewIdent("depth"),
+					},
+					Tok: token.DEC,
+				},
+			},
+		},
+	}
+}
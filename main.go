@@ -37,7 +37,10 @@ func newMethodFlag(flagName, value string) *method {
 }
 
 func run() error {
-	var output string
+	var (
+		output string
+		conf   config
+	)
 
 	methods := []*method{
 		newMethodFlag("w", "WriteTo"),
@@ -45,9 +48,27 @@ func run() error {
 		newMethodFlag("a", "AppendBinary"),
 		newMethodFlag("m", "MarshalBinary"),
 		newMethodFlag("u", "UnmarshalBinary"),
+		newMethodFlag("s", "BinarySize"),
 	}
 
 	flag.StringVar(&output, "o", "", "output file")
+	flag.BoolVar(&conf.bigEndian, "bigendian", false, "use big-endian byte order by default")
+	flag.BoolVar(&conf.varint, "varint", false, "encode integers as variable-length (zigzag for signed) values")
+	flag.BoolVar(&conf.sortKeys, "sortkeys", false, "sort map keys so that encoding is deterministic")
+	flag.BoolVar(&conf.skip, "skipunsupported", false, "skip fields of unsupported types instead of failing")
+	flag.BoolVar(&conf.tagged, "tagged", false, "encode struct fields with a tag and length so that unknown fields can be skipped")
+	flag.BoolVar(&conf.omitZero, "omitzero", false, "write a presence bitmap and omit struct fields with zero values")
+	flag.BoolVar(&conf.keepNil, "keepnil", false, "encode whether slices and maps are nil so that nil and empty values survive a round trip")
+	flag.BoolVar(&conf.refs, "refs", false, "encode pointers as references so that shared and cyclic pointers survive a round trip")
+	flag.BoolVar(&conf.zeroCopy, "zerocopy", false, "decode strings and byte slices by referring to the input buffer instead of copying")
+	flag.BoolVar(&conf.reuse, "reuse", false, "decode into the existing capacity of slices and maps instead of allocating new ones")
+	flag.BoolVar(&conf.unexported, "unexported", false, "include unexported struct fields")
+	flag.BoolVar(&conf.strict, "strict", false, "make UnmarshalBinary return an error when bytes remain after decoding")
+	flag.Uint64Var(&conf.limits.slice, "maxslice", 0, "maximum length of a decoded slice (0 for no limit)")
+	flag.Uint64Var(&conf.limits.mapSize, "maxmap", 0, "maximum number of entries in a decoded map (0 for no limit)")
+	flag.Uint64Var(&conf.limits.str, "maxstring", 0, "maximum length of a decoded string (0 for no limit)")
+	flag.Uint64Var(&conf.limits.alloc, "maxalloc", 0, "maximum number of bytes allocated while decoding (0 for no limit)")
+	flag.Uint64Var(&conf.limits.depth, "maxdepth", 0, "maximum nesting depth of decoding calls (0 for no limit)")
 
 	flag.Parse()
 
@@ -66,11 +87,23 @@ func run() error {
 		return err
 	}
 
-	args := append([]string{"-o", filepath.Base(output)}, flag.Args()...)
+	dirs, err := parseDirectives(filepath.Dir(output), output)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-o", filepath.Base(output)}
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "o" {
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
 
+	args = append(args, flag.Args()...)
 	fw := fileWriter{path: output}
 
-	if err := constructFile(&fw, pkg.Name(), methods[2].value, methods[3].value, methods[4].value, methods[0].value, methods[1].value, args, pkg, flag.Args()...); err != nil {
+	if err := constructFile(&fw, pkg.Name(), methods[2].value, methods[3].value, methods[4].value, methods[0].value, methods[1].value, methods[5].value, conf, dirs, args, pkg, flag.Args()...); err != nil {
 		return err
 	}
 
@@ -96,8 +129,14 @@ func (f *fileWriter) Write(p []byte) (int, error) {
 }
 
 var (
-	ErrNoOutput    = errors.New("no output file")
-	ErrNotFound    = errors.New("typename not found")
-	ErrNotAType    = errors.New("identifier is not a named type")
-	ErrGenericType = errors.New("generic types are currently unsupported")
+	ErrNoOutput         = errors.New("no output file")
+	ErrNotFound         = errors.New("typename not found")
+	ErrNotAType         = errors.New("identifier is not a named type")
+	ErrUnknownDirective = errors.New("unknown directive")
+	ErrInvalidTag       = errors.New("invalid struct tag")
+	ErrUnsortableKey    = errors.New("map key type cannot be sorted")
+	ErrRecursiveType    = errors.New("recursive type is not accessible")
+	ErrUnsupportedType  = errors.New("unsupported type")
+	ErrInvalidUnion     = errors.New("invalid union member")
+	ErrInvalidVersion   = errors.New("invalid version")
 )
@@ -1,36 +1,21 @@
 package main
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
-	"strings"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
 )
 
 func marshalName(typ *types.Named) string {
-	return "_marshal_" + strings.ReplaceAll(strings.ReplaceAll(typ.Obj().Name(), "_", "__"), ".", "_")
+	return "_marshal_" + funcSuffix(typ)
 }
 
-func (c *constructor) imports(includeStdlib bool) *ast.GenDecl {
-	var imports []ast.Spec
-
-	if includeStdlib {
-		imports = []ast.Spec{
-			&ast.ImportSpec{
-				Path: &ast.BasicLit{
-					Kind:  token.STRING,
-					Value: `"cmp"`,
-				},
-			},
-			&ast.ImportSpec{
-				Path: &ast.BasicLit{
-					Kind:  token.STRING,
-					Value: `"io"`,
-				},
-			},
-		}
-	}
-
+func (c *constructor) imports() *ast.GenDecl {
 	return &ast.GenDecl{
 		Doc: &ast.CommentGroup{
 			List: []*ast.Comment{
@@ -42,23 +27,57 @@ func (c *constructor) imports(includeStdlib bool) *ast.GenDecl {
 		},
 		TokPos: c.newLine(),
 		Tok:    token.IMPORT,
-		Specs: append(imports, &ast.ImportSpec{
+		Specs: []ast.Spec{
+			&ast.ImportSpec{
+				Path: &ast.BasicLit{
+					Kind:     token.STRING,
+					Value:    `"vimagination.zapto.org/byteio"`,
+					ValuePos: c.newLine(),
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) use(pkg string) {
+	c.packages[pkg] = path.Base(pkg)
+}
+
+func (c *constructor) usePackage(pkg *types.Package) {
+	c.packages[pkg.Path()] = pkg.Name()
+}
+
+func (c *constructor) importSpecs() []ast.Spec {
+	var imports []ast.Spec
+
+	for _, pkg := range slices.Sorted(maps.Keys(c.packages)) {
+		var name *ast.Ident
+
+		if c.packages[pkg] != path.Base(pkg) {
+			name = ast.NewIdent(c.packages[pkg])
+		}
+
+		imports = append(imports, &ast.ImportSpec{
+			Name: name,
 			Path: &ast.BasicLit{
-				Kind:     token.STRING,
-				Value:    `"vimagination.zapto.org/byteio"`,
-				ValuePos: c.newLine(),
+				Kind:  token.STRING,
+				Value: strconv.Quote(pkg),
 			},
-		}),
+		})
 	}
+
+	return imports
 }
 
-func (c *constructor) assignBinary(typeName, funcName, marshalName string) *ast.FuncDecl {
+func (c *constructor) assignBinary(typeName ast.Expr, funcName, marshalName string) *ast.FuncDecl {
 	comment := "// AppendBinary implements the encoding.BinaryAppender interface."
 
 	if funcName != "AppendBinary" {
 		comment = "// " + funcName + " appends the binary representation of itself to the end of b\n// (allocating a larger slice if necessary) and returns the updated slice."
 	}
 
+	comment += "\n//\n// The data is encoded using " + c.endianComment() + " byte order."
+
 	return &ast.FuncDecl{
 		Doc: &ast.CommentGroup{
 			List: []*ast.Comment{
@@ -76,7 +95,7 @@ func (c *constructor) assignBinary(typeName, funcName, marshalName string) *ast.
 					},
 					Type: &ast.UnaryExpr{
 						Op: token.MUL,
-						X:  ast.NewIdent(typeName),
+						X:  typeName,
 					},
 				},
 			},
@@ -121,7 +140,7 @@ func (c *constructor) assignBinary(typeName, funcName, marshalName string) *ast.
 						&ast.CallExpr{
 							Fun: &ast.SelectorExpr{
 								X:   ast.NewIdent("byteio"),
-								Sel: ast.NewIdent("MemLittleEndian"),
+								Sel: ast.NewIdent("Mem" + c.endian()),
 							},
 							Args: []ast.Expr{
 								ast.NewIdent("b"),
@@ -137,13 +156,13 @@ func (c *constructor) assignBinary(typeName, funcName, marshalName string) *ast.
 					Rhs: []ast.Expr{
 						&ast.CallExpr{
 							Fun: ast.NewIdent(marshalName),
-							Args: []ast.Expr{
+							Args: c.newRefsArgs(
 								ast.NewIdent("t"),
 								&ast.UnaryExpr{
 									Op: token.AND,
 									X:  ast.NewIdent("eb"),
 								},
-							},
+							),
 						},
 					},
 				},
@@ -159,13 +178,44 @@ func (c *constructor) assignBinary(typeName, funcName, marshalName string) *ast.
 	}
 }
 
-func (c *constructor) marshalBinary(typeName, funcName, marshalName string) *ast.FuncDecl {
+func (c *constructor) marshalBinary(typeName ast.Expr, funcName, marshalName, sizer string) *ast.FuncDecl {
 	comment := "// MarshalBinary implements the encoding.BinaryMarshaler interface."
 
 	if funcName != "MarshalBinary" {
 		comment = "// " + funcName + " encodes the receiver into a binary form and returns the result."
 	}
 
+	comment += "\n//\n// The data is encoded using " + c.endianComment() + " byte order."
+
+	var buf ast.Expr = &ast.CompositeLit{
+		Type: &ast.SelectorExpr{
+			X:   ast.NewIdent("byteio"),
+			Sel: ast.NewIdent("Mem" + c.endian()),
+		},
+	}
+
+	if sizer != "" {
+		buf = &ast.CallExpr{
+			Fun: ast.NewIdent("make"),
+			Args: []ast.Expr{
+				&ast.SelectorExpr{
+					X:   ast.NewIdent("byteio"),
+					Sel: ast.NewIdent("Mem" + c.endian()),
+				},
+				&ast.BasicLit{
+					Kind:  token.INT,
+					Value: "0",
+				},
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent("t"),
+						Sel: ast.NewIdent(sizer),
+					},
+				},
+			},
+		}
+	}
+
 	return &ast.FuncDecl{
 		Doc: &ast.CommentGroup{
 			List: []*ast.Comment{
@@ -183,7 +233,7 @@ func (c *constructor) marshalBinary(typeName, funcName, marshalName string) *ast
 					},
 					Type: &ast.UnaryExpr{
 						Op: token.MUL,
-						X:  ast.NewIdent(typeName),
+						X:  typeName,
 					},
 				},
 			},
@@ -213,14 +263,7 @@ func (c *constructor) marshalBinary(typeName, funcName, marshalName string) *ast
 						ast.NewIdent("eb"),
 					},
 					Tok: token.DEFINE,
-					Rhs: []ast.Expr{
-						&ast.CompositeLit{
-							Type: &ast.SelectorExpr{
-								X:   ast.NewIdent("byteio"),
-								Sel: ast.NewIdent("MemLittleEndian"),
-							},
-						},
-					},
+					Rhs: []ast.Expr{buf},
 				},
 				&ast.AssignStmt{
 					Lhs: []ast.Expr{
@@ -230,13 +273,13 @@ func (c *constructor) marshalBinary(typeName, funcName, marshalName string) *ast
 					Rhs: []ast.Expr{
 						&ast.CallExpr{
 							Fun: ast.NewIdent(marshalName),
-							Args: []ast.Expr{
+							Args: c.newRefsArgs(
 								ast.NewIdent("t"),
 								&ast.UnaryExpr{
 									Op: token.AND,
 									X:  ast.NewIdent("eb"),
 								},
-							},
+							),
 						},
 					},
 				},
@@ -252,13 +295,69 @@ func (c *constructor) marshalBinary(typeName, funcName, marshalName string) *ast
 	}
 }
 
-func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncDecl {
+func (c *constructor) binarySize(typeName ast.Expr, funcName string, size ast.Expr) *ast.FuncDecl {
+	comment := "// BinarySize returns the number of bytes needed to encode the receiver."
+
+	if funcName != "BinarySize" {
+		comment = "// " + funcName + " returns the number of bytes needed to encode the receiver."
+	}
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Slash: c.newLine(),
+					Text:  comment,
+				},
+			},
+		},
+		Recv: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent("t"),
+					},
+					Type: &ast.UnaryExpr{
+						Op: token.MUL,
+						X:  typeName,
+					},
+				},
+			},
+		},
+		Name: &ast.Ident{
+			Name: funcName,
+		},
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						size,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) writeTo(typeName ast.Expr, funcName, marshalName string) *ast.FuncDecl {
 	comment := "// WriteTo implements the io.WriterTo interface."
 
 	if funcName != "WriteTo" {
 		comment = "// " + funcName + " writes data to w until there's no more data to write or when an error occurs.\n//\n// The return value n is the number of bytes written. Any error encountered during the write is also returned."
 	}
 
+	comment += "\n//\n// Unless w is a byteio writer with its own byte order, the data is encoded using " + c.endianComment() + " byte order."
+
 	return &ast.FuncDecl{
 		Doc: &ast.CommentGroup{
 			List: []*ast.Comment{
@@ -276,7 +375,7 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 					},
 					Type: &ast.UnaryExpr{
 						Op: token.MUL,
-						X:  ast.NewIdent(typeName),
+						X:  typeName,
 					},
 				},
 			},
@@ -361,10 +460,10 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: ast.NewIdent(marshalName),
-												Args: []ast.Expr{
+												Args: c.newRefsArgs(
 													ast.NewIdent("t"),
 													ast.NewIdent("w"),
-												},
+												),
 											},
 										},
 									},
@@ -430,10 +529,10 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: ast.NewIdent(marshalName),
-												Args: []ast.Expr{
+												Args: c.newRefsArgs(
 													ast.NewIdent("t"),
 													ast.NewIdent("w"),
-												},
+												),
 											},
 										},
 									},
@@ -493,7 +592,7 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 												Sel: ast.NewIdent("Err"),
 											},
 										},
-										Tok: token.DEFINE,
+										Tok: token.ASSIGN,
 										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: &ast.SelectorExpr{
@@ -507,10 +606,10 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 													},
 													&ast.CallExpr{
 														Fun: ast.NewIdent(marshalName),
-														Args: []ast.Expr{
+														Args: c.newRefsArgs(
 															ast.NewIdent("t"),
 															ast.NewIdent("w"),
-														},
+														),
 													},
 												},
 											},
@@ -527,7 +626,10 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 												Op: token.SUB,
 												Y:  ast.NewIdent("l"),
 											},
-											ast.NewIdent("err"),
+											&ast.SelectorExpr{
+												X:   ast.NewIdent("w"),
+												Sel: ast.NewIdent("Err"),
+											},
 										},
 									},
 								},
@@ -562,7 +664,7 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 												Sel: ast.NewIdent("Err"),
 											},
 										},
-										Tok: token.DEFINE,
+										Tok: token.ASSIGN,
 										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: &ast.SelectorExpr{
@@ -576,10 +678,10 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 													},
 													&ast.CallExpr{
 														Fun: ast.NewIdent(marshalName),
-														Args: []ast.Expr{
+														Args: c.newRefsArgs(
 															ast.NewIdent("t"),
 															ast.NewIdent("w"),
-														},
+														),
 													},
 												},
 											},
@@ -596,7 +698,10 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 												Op: token.SUB,
 												Y:  ast.NewIdent("l"),
 											},
-											ast.NewIdent("err"),
+											&ast.SelectorExpr{
+												X:   ast.NewIdent("w"),
+												Sel: ast.NewIdent("Err"),
+											},
 										},
 									},
 								},
@@ -616,7 +721,7 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 						&ast.CompositeLit{
 							Type: &ast.SelectorExpr{
 								X:   ast.NewIdent("byteio"),
-								Sel: ast.NewIdent("StickyLittleEndianWriter"),
+								Sel: ast.NewIdent("Sticky" + c.endian() + "Writer"),
 							},
 							Elts: []ast.Expr{
 								&ast.KeyValueExpr{
@@ -641,13 +746,13 @@ func (c *constructor) writeTo(typeName, funcName, marshalName string) *ast.FuncD
 							Args: []ast.Expr{
 								&ast.CallExpr{
 									Fun: ast.NewIdent(marshalName),
-									Args: []ast.Expr{
+									Args: c.newRefsArgs(
 										ast.NewIdent("t"),
 										&ast.UnaryExpr{
 											Op: token.AND,
 											X:  ast.NewIdent("sw"),
 										},
-									},
+									),
 								},
 								&ast.SelectorExpr{
 									X:   ast.NewIdent("sw"),
@@ -677,6 +782,40 @@ func (c *constructor) addStatement(stmt ast.Stmt) {
 }
 
 func (c *constructor) writeType(name ast.Expr, typ types.Type) {
+	if size, ok := c.fixedSize(typ); ok && c.sizing {
+		c.addSize(intLit(size))
+
+		return
+	}
+
+	if named, ok := typ.(*types.Named); ok {
+		if fn := stdlibFunc(named); fn != "" {
+			c.callHelper("_marshal_"+fn, "w", name)
+
+			return
+		} else if names, ok := c.callable(named); ok {
+			c.callFunc(names[0], "w", name)
+
+			return
+		} else if fn := binaryFunc(named, true); fn != "" {
+			c.callHelper(fn, "w", name)
+
+			return
+		}
+
+		c.inlining[named.Origin()] = true
+
+		defer delete(c.inlining, named.Origin())
+	} else if tp, ok := typ.(*types.TypeParam); ok {
+		c.callParam(true, name, tp)
+
+		return
+	}
+
+	c.writeUnderlying(name, typ)
+}
+
+func (c *constructor) writeUnderlying(name ast.Expr, typ types.Type) {
 	switch t := typ.Underlying().(type) {
 	case *types.Struct:
 		c.writeStruct(name, t)
@@ -689,13 +828,39 @@ func (c *constructor) writeType(name ast.Expr, typ types.Type) {
 	case *types.Pointer:
 		c.writePointer(name, t)
 	case *types.Basic:
-		c.writeBasic(name, t)
+		c.writeBasic(name, typ, t)
+	case *types.Interface:
+		c.writeUnion(name, typ, t)
+	default:
+		c.unsupported(typ)
 	}
 }
 
 func (c *constructor) writeStruct(name ast.Expr, t *types.Struct) {
-	for field := range t.Fields() {
-		if !field.Exported() {
+	if star, ok := name.(*ast.StarExpr); ok {
+		name = star.X
+	}
+
+	fields, err := c.structFields(t)
+	if err != nil {
+		c.setError(err)
+
+		return
+	}
+
+	conf := c.config
+	path := c.path
+
+	for _, field := range fields {
+		if c.config, err = conf.field(field); err != nil {
+			c.setError(err)
+		} else if c.skip && !c.supported(field.Type(), nil) {
+			continue
+		}
+
+		c.path = path + "." + field.Name()
+
+		if c.versioned(field) && field.removed > 0 {
 			continue
 		}
 
@@ -704,9 +869,18 @@ func (c *constructor) writeStruct(name ast.Expr, t *types.Struct) {
 			Sel: ast.NewIdent(field.Name()),
 		}, field.Type())
 	}
+
+	c.config = conf
+	c.path = path
 }
 
 func (c *constructor) addWriter(method string, name ast.Expr) {
+	if c.sizing {
+		c.addSize(c.writerSize(method, name))
+
+		return
+	}
+
 	c.addCall(&ast.SelectorExpr{
 		X:   ast.NewIdent("w"),
 		Sel: ast.NewIdent(method),
@@ -715,20 +889,59 @@ func (c *constructor) addWriter(method string, name ast.Expr) {
 
 func (c *constructor) subConstructor() *constructor {
 	return &constructor{
-		pkg:   c.pkg,
-		pos:   c.pos,
-		types: c.types,
+		pkg:      c.pkg,
+		pos:      c.pos,
+		config:   c.config,
+		types:    c.types,
+		configs:  c.configs,
+		packages: c.packages,
+		helpers:  c.helpers,
+		inlining: c.inlining,
+		queue:    c.queue,
+		defaults: c.defaults,
+		dirs:     c.dirs,
+		path:     c.path,
+		errPath:  c.errPath,
+		depth:    c.depth + 1,
+		sizing:   c.sizing,
+		err:      c.err,
 	}
 }
 
+func (c *constructor) writeLength(name ast.Expr) {
+	method, kind := c.length()
+
+	c.addWriter("Write"+method, &ast.CallExpr{
+		Fun: ast.NewIdent(types.Typ[kind].Name()),
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  ast.NewIdent("len"),
+				Args: []ast.Expr{name},
+			},
+		},
+	})
+}
+
 func (c *constructor) writeArray(name ast.Expr, t *types.Array) {
+	if size, ok := c.fixedSize(t.Elem()); ok && c.sizing {
+		if size > 0 {
+			c.addSize(mulSize(lenCall(name), size))
+		}
+
+		return
+	} else if c.writeBulk(&ast.SliceExpr{X: name}, t.Elem()) {
+		return
+	}
+
 	d := c.subConstructor()
+	e := c.varName("e")
+	d.path += "[]"
 
-	d.writeType(ast.NewIdent("e"), t.Elem())
-	c.addStatement(&ast.RangeStmt{
+	d.writeType(e, t.Elem())
+	c.addRange(&ast.RangeStmt{
 		For:   c.newLine(),
 		Key:   ast.NewIdent("_"),
-		Value: ast.NewIdent("e"),
+		Value: e,
 		Tok:   token.DEFINE,
 		X:     name,
 		Body: &ast.BlockStmt{
@@ -738,62 +951,439 @@ func (c *constructor) writeArray(name ast.Expr, t *types.Array) {
 }
 
 func (c *constructor) writeSlice(name ast.Expr, t *types.Slice) {
-	c.addWriter("WriteUintX", &ast.CallExpr{
-		Fun: ast.NewIdent("uint64"),
-		Args: []ast.Expr{
-			&ast.CallExpr{
-				Fun:  ast.NewIdent("len"),
-				Args: []ast.Expr{name},
-			},
-		},
+	c.writeNilable(name, func(d *constructor) {
+		d.writeLength(name)
+
+		if !d.writeBulk(name, t.Elem()) {
+			d.writeArray(name, types.NewArray(t.Elem(), 0))
+		}
 	})
-	c.writeArray(name, types.NewArray(t.Elem(), 0))
+}
+
+func (c *constructor) writeNilable(name ast.Expr, fn func(*constructor)) {
+	if !c.keepNil {
+		fn(c)
+
+		return
+	}
+
+	d := c.subConstructor()
+
+	fn(d)
+	c.writeNonNil(name, d.statements)
 }
 
 func (c *constructor) writeMap(name ast.Expr, t *types.Map) {
+	c.writeNilable(name, func(d *constructor) {
+		d.writeMapEntries(name, t)
+	})
+}
+
+func (c *constructor) writeMapEntries(name ast.Expr, t *types.Map) {
+	if c.sizing {
+		c.sizeMapEntries(name, t)
+
+		return
+	}
+
 	d := c.subConstructor()
+	k := c.varName("k")
+	v := c.varName("v")
 
-	d.writeType(ast.NewIdent("k"), t.Key())
-	d.writeType(ast.NewIdent("v"), t.Elem())
-	c.addWriter("WriteUintX", &ast.CallExpr{
-		Fun: ast.NewIdent("uint64"),
-		Args: []ast.Expr{
-			&ast.CallExpr{
-				Fun:  ast.NewIdent("len"),
-				Args: []ast.Expr{name},
+	c.writeLength(name)
+
+	var value ast.Expr
+
+	if d.writeKeyValue(k, v, t) {
+		value = v
+	}
+
+	if !c.sortKeys {
+		c.addStatement(&ast.RangeStmt{
+			For:   c.newLine(),
+			Key:   k,
+			Value: value,
+			Tok:   token.DEFINE,
+			X:     name,
+			Body: &ast.BlockStmt{
+				List: d.statements,
 			},
-		},
-	})
+		})
+
+		return
+	}
+
+	if value != nil {
+		d.statements = append([]ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{
+					v,
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.IndexExpr{
+						X:     name,
+						Index: k,
+					},
+				},
+			},
+		}, d.statements...)
+	}
+
 	c.addStatement(&ast.RangeStmt{
 		For:   c.newLine(),
-		Key:   ast.NewIdent("k"),
-		Value: ast.NewIdent("v"),
+		Key:   ast.NewIdent("_"),
+		Value: k,
 		Tok:   token.DEFINE,
-		X:     name,
+		X:     c.sortedKeys(name, t.Key()),
 		Body: &ast.BlockStmt{
 			List: d.statements,
 		},
 	})
 }
 
-func (c *constructor) writePointer(name ast.Expr, t *types.Pointer) {
-	d := c.subConstructor()
+func (c *constructor) writeKeyValue(k, v ast.Expr, t *types.Map) bool {
+	path := c.path
+	c.path = path + "[key]"
+
+	c.writeType(k, t.Key())
+
+	c.path = path + "[]"
+	l := len(c.statements)
+
+	c.writeType(v, t.Elem())
+
+	c.path = path
+
+	return len(c.statements) > l
+}
+
+func (c *constructor) sortedKeys(name ast.Expr, key types.Type) ast.Expr {
+	c.use("maps")
+	c.use("slices")
+
+	keys := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("maps"),
+			Sel: ast.NewIdent("Keys"),
+		},
+		Args: []ast.Expr{name},
+	}
+
+	if isOrdered(key) {
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("slices"),
+				Sel: ast.NewIdent("Sorted"),
+			},
+			Args: []ast.Expr{keys},
+		}
+	}
+
+	compare := c.compareFunc(key, 0)
+	if compare == nil {
+		c.setError(fmt.Errorf("%w: %s", ErrUnsortableKey, key))
+
+		return keys
+	}
 
-	d.writeType(name, t.Elem())
-	c.addStatement(&ast.ExprStmt{
-		X: &ast.CallExpr{
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("slices"),
+			Sel: ast.NewIdent("SortedFunc"),
+		},
+		Args: []ast.Expr{keys, compare},
+	}
+}
+
+func isOrdered(typ types.Type) bool {
+	if tp, ok := typ.(*types.TypeParam); ok {
+		return types.Implements(tp, orderedConstraint)
+	}
+
+	basic, ok := typ.Underlying().(*types.Basic)
+
+	return ok && basic.Info()&types.IsOrdered != 0
+}
+
+func (c *constructor) compareFunc(typ types.Type, depth int) ast.Expr {
+	typename := c.accessibleIdent(typ)
+	if typename == nil {
+		return nil
+	}
+
+	a := ast.NewIdent("a" + strconv.Itoa(depth))
+	b := ast.NewIdent("b" + strconv.Itoa(depth))
+
+	compare := c.compare(a, b, typ, depth)
+	if compare == nil {
+		return nil
+	}
+
+	return &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{a, b},
+						Type:  typename,
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{compare},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) compare(a, b ast.Expr, typ types.Type, depth int) ast.Expr {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		return c.compareBasic(a, b, t)
+	case *types.Struct:
+		return c.compareStruct(a, b, t, depth)
+	case *types.Array:
+		return c.compareArray(a, b, t, depth)
+	}
+
+	return nil
+}
+
+func (c *constructor) compareBasic(a, b ast.Expr, t *types.Basic) ast.Expr {
+	c.use("cmp")
+
+	switch {
+	case t.Info()&types.IsOrdered != 0:
+		return &ast.CallExpr{
 			Fun: &ast.SelectorExpr{
-				X:   ast.NewIdent("w"),
-				Sel: ast.NewIdent("WriteBool"),
+				X:   ast.NewIdent("cmp"),
+				Sel: ast.NewIdent("Compare"),
+			},
+			Args: []ast.Expr{a, b},
+		}
+	case t.Info()&types.IsBoolean != 0:
+		c.helpers["_compare_bool"] = true
+
+		return &ast.CallExpr{
+			Fun:  ast.NewIdent("_compare_bool"),
+			Args: []ast.Expr{a, b},
+		}
+	case t.Info()&types.IsComplex != 0:
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("cmp"),
+				Sel: ast.NewIdent("Or"),
 			},
 			Args: []ast.Expr{
-				&ast.BinaryExpr{
-					X:  name,
-					Op: token.NEQ,
-					Y:  ast.NewIdent("nil"),
+				c.compareBasic(&ast.CallExpr{
+					Fun:  ast.NewIdent("real"),
+					Args: []ast.Expr{a},
+				}, &ast.CallExpr{
+					Fun:  ast.NewIdent("real"),
+					Args: []ast.Expr{b},
+				}, types.Typ[types.Float64]),
+				c.compareBasic(&ast.CallExpr{
+					Fun:  ast.NewIdent("imag"),
+					Args: []ast.Expr{a},
+				}, &ast.CallExpr{
+					Fun:  ast.NewIdent("imag"),
+					Args: []ast.Expr{b},
+				}, types.Typ[types.Float64]),
+			},
+		}
+	}
+
+	return nil
+}
+
+func (c *constructor) compareStruct(a, b ast.Expr, t *types.Struct, depth int) ast.Expr {
+	var fields []ast.Expr
+
+	for field := range t.Fields() {
+		if field.Name() == "_" {
+			continue
+		} else if !field.Exported() && field.Pkg() != c.pkg {
+			return nil
+		}
+
+		compare := c.compare(&ast.SelectorExpr{
+			X:   a,
+			Sel: ast.NewIdent(field.Name()),
+		}, &ast.SelectorExpr{
+			X:   b,
+			Sel: ast.NewIdent(field.Name()),
+		}, field.Type(), depth)
+		if compare == nil {
+			return nil
+		}
+
+		fields = append(fields, compare)
+	}
+
+	switch len(fields) {
+	case 0:
+		return &ast.BasicLit{
+			Kind:  token.INT,
+			Value: "0",
+		}
+	case 1:
+		return fields[0]
+	}
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("cmp"),
+			Sel: ast.NewIdent("Or"),
+		},
+		Args: fields,
+	}
+}
+
+func (c *constructor) compareArray(a, b ast.Expr, t *types.Array, depth int) ast.Expr {
+	c.use("slices")
+
+	as := &ast.SliceExpr{X: a}
+	bs := &ast.SliceExpr{X: b}
+
+	if isOrdered(t.Elem()) {
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("slices"),
+				Sel: ast.NewIdent("Compare"),
+			},
+			Args: []ast.Expr{as, bs},
+		}
+	}
+
+	compare := c.compareFunc(t.Elem(), depth+1)
+	if compare == nil {
+		return nil
+	}
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("slices"),
+			Sel: ast.NewIdent("CompareFunc"),
+		},
+		Args: []ast.Expr{as, bs, compare},
+	}
+}
+
+func compareBool() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_compare_bool"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("T")},
+						Type: &ast.UnaryExpr{
+							Op: token.TILDE,
+							X:  ast.NewIdent("bool"),
+						},
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("a"),
+							ast.NewIdent("b"),
+						},
+						Type: ast.NewIdent("T"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("int"),
+					},
 				},
 			},
 		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IfStmt{
+					Cond: &ast.BinaryExpr{
+						X:  ast.NewIdent("a"),
+						Op: token.EQL,
+						Y:  ast.NewIdent("b"),
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ReturnStmt{
+								Results: []ast.Expr{
+									&ast.BasicLit{
+										Kind:  token.INT,
+										Value: "0",
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.IfStmt{
+					Cond: ast.NewIdent("a"),
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ReturnStmt{
+								Results: []ast.Expr{
+									&ast.BasicLit{
+										Kind:  token.INT,
+										Value: "1",
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.UnaryExpr{
+							Op: token.SUB,
+							X: &ast.BasicLit{
+								Kind:  token.INT,
+								Value: "1",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) writePointer(name ast.Expr, t *types.Pointer) {
+	d := c.subConstructor()
+
+	d.writeType(&ast.StarExpr{X: name}, t.Elem())
+
+	if c.refs {
+		c.writeRef(name, d.statements)
+	} else {
+		c.writeNonNil(name, d.statements)
+	}
+}
+
+func (c *constructor) writeNonNil(name ast.Expr, body []ast.Stmt) {
+	c.addWriter("WriteBool", &ast.BinaryExpr{
+		X:  name,
+		Op: token.NEQ,
+		Y:  ast.NewIdent("nil"),
 	})
 	c.addStatement(&ast.IfStmt{
 		Cond: &ast.BinaryExpr{
@@ -802,39 +1392,67 @@ func (c *constructor) writePointer(name ast.Expr, t *types.Pointer) {
 			Y:  ast.NewIdent("nil"),
 		},
 		Body: &ast.BlockStmt{
-			List: d.statements,
+			List: body,
 		},
 	})
 }
 
-func (c *constructor) writeBasic(name ast.Expr, t *types.Basic) {
-	switch t.Kind() {
+func (c *constructor) basicMethod(kind types.BasicKind) (string, types.BasicKind) {
+	switch kind {
 	case types.Bool:
-		c.addWriter("WriteBool", name)
-	case types.Int:
-		c.addWriter("WriteInt64", name)
+		return "Bool", types.Bool
 	case types.Int8:
-		c.addWriter("WriteInt8", name)
+		return "Int8", types.Int8
+	case types.Uint8:
+		return "Uint8", types.Uint8
+	case types.Float32:
+		return "Float32", types.Float32
+	case types.Float64:
+		return "Float64", types.Float64
+	case types.String:
+		return c.stringMethod(), types.String
+	}
+
+	if c.varint {
+		switch kind {
+		case types.Int, types.Int16, types.Int32, types.Int64:
+			return "IntX", types.Int64
+		case types.Uint, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+			return "UintX", types.Uint64
+		}
+	}
+
+	switch kind {
 	case types.Int16:
-		c.addWriter("WriteInt16", name)
+		return "Int16", types.Int16
 	case types.Int32:
-		c.addWriter("WriteInt32", name)
-	case types.Int64:
-		c.addWriter("WriteInt64", name)
-	case types.Uint:
-		c.addWriter("WriteUint64", name)
-	case types.Uint8:
-		c.addWriter("WriteUint8", name)
+		return "Int32", types.Int32
+	case types.Int, types.Int64:
+		return "Int64", types.Int64
 	case types.Uint16:
-		c.addWriter("WriteUint16", name)
+		return "Uint16", types.Uint16
 	case types.Uint32:
-		c.addWriter("WriteUint32", name)
-	case types.Uint64, types.Uintptr:
-		c.addWriter("WriteUint64", name)
-	case types.Float32:
-		c.addWriter("WriteFloat32", name)
-	case types.Float64:
-		c.addWriter("WriteFloat64", name)
+		return "Uint32", types.Uint32
+	case types.Uint, types.Uint64, types.Uintptr:
+		return "Uint64", types.Uint64
+	}
+
+	return "", types.Invalid
+}
+
+func convert(name ast.Expr, typ types.Type, kind types.BasicKind) ast.Expr {
+	if types.Identical(typ, types.Typ[kind]) {
+		return name
+	}
+
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent(types.Typ[kind].Name()),
+		Args: []ast.Expr{name},
+	}
+}
+
+func (c *constructor) writeBasic(name ast.Expr, typ types.Type, t *types.Basic) {
+	switch t.Kind() {
 	case types.Complex64:
 		c.addWriter("WriteFloat32", &ast.CallExpr{
 			Fun:  ast.NewIdent("real"),
@@ -853,79 +1471,39 @@ func (c *constructor) writeBasic(name ast.Expr, t *types.Basic) {
 			Fun:  ast.NewIdent("imag"),
 			Args: []ast.Expr{name},
 		})
-	case types.String:
-		c.addWriter("WriteStringX", name)
-	}
-}
-
-func deref(typ types.Type) types.Type {
-	if ptr, ok := typ.(*types.Pointer); ok {
-		return ptr.Elem()
+	default:
+		if method, kind := c.basicMethod(t.Kind()); method != "" {
+			c.addWriter("Write"+method, convert(name, typ, kind))
+		} else {
+			c.unsupported(typ)
+		}
 	}
-
-	return typ
 }
 
 func (c *constructor) marshalFunc(typ *types.Named) *ast.FuncDecl {
-	typeName := typ.Obj().Name()
-	marshalName := marshalName(typ)
 	c.statements = nil
+	c.path = typ.Obj().Name()
 
-	c.writeType(ast.NewIdent("t"), deref(typ.Underlying()))
+	c.marshalBody(typ)
 
-	return &ast.FuncDecl{
-		Name: &ast.Ident{
-			Name: marshalName,
-		},
-		Type: &ast.FuncType{
-			Func: c.newLine(),
-			TypeParams: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Names: []*ast.Ident{
-							ast.NewIdent("W"),
-						},
-						Type: &ast.SelectorExpr{
-							X:   ast.NewIdent("byteio"),
-							Sel: ast.NewIdent("StickyWriter"),
-						},
-					},
-				},
-			},
-			Params: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Names: []*ast.Ident{
-							ast.NewIdent("t"),
-						},
-						Type: &ast.UnaryExpr{
-							Op: token.MUL,
-							X:  ast.NewIdent(typeName),
-						},
-					},
-					{
-						Names: []*ast.Ident{
-							ast.NewIdent("w"),
-						},
-						Type: ast.NewIdent("W"),
-					},
-				},
-			},
-			Results: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Type: ast.NewIdent("error"),
-					},
-				},
-			},
-		},
-		Body: &ast.BlockStmt{
-			List: append(c.statements, &ast.ReturnStmt{
-				Return: c.newLine(),
-				Results: []ast.Expr{
-					ast.NewIdent("nil"),
-				},
-			}),
+	params, typeParam := c.typeParams(typ, "W")
+
+	return c.streamFunc(marshalName(typ), true, params, typeParam, c.accessibleIdent(typ), append(c.statements, &ast.ReturnStmt{
+		Return: c.newLine(),
+		Results: []ast.Expr{
+			ast.NewIdent("nil"),
 		},
+	}))
+}
+
+func (c *constructor) marshalBody(typ *types.Named) {
+	c.writeVersion()
+
+	if t, ok := c.taggedStruct(typ); ok {
+		c.writeTagged(t)
+	} else if t, ok := c.sparseStruct(typ); ok {
+		c.writeSparse(t)
+	} else {
+		c.writeUnderlying(&ast.StarExpr{X: ast.NewIdent("t")}, typ)
 	}
 }
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+func (c *constructor) sparseStruct(typ types.Type) (*types.Struct, bool) {
+	if !c.omitZero {
+		return nil, false
+	}
+
+	t, ok := typ.Underlying().(*types.Struct)
+
+	return t, ok
+}
+
+func omittable(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Basic, *types.Slice, *types.Map:
+		return true
+	case *types.Pointer, *types.Interface:
+		if _, ok := typ.(*types.TypeParam); !ok {
+			return true
+		}
+	}
+
+	return types.Comparable(typ) && !hasFloat(typ)
+}
+
+func (c *constructor) nonZero(name ast.Expr, typ types.Type) ast.Expr {
+	if !omittable(typ) {
+		return nil
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return name
+		case t.Info()&types.IsString != 0:
+			return &ast.BinaryExpr{
+				X:  name,
+				Op: token.NEQ,
+				Y: &ast.BasicLit{
+					Kind:  token.STRING,
+					Value: `""`,
+				},
+			}
+		case t.Info()&types.IsFloat != 0:
+			return c.nonZeroFloat(convert(name, typ, t.Kind()), t.Kind())
+		case t.Kind() == types.Complex64:
+			return c.nonZeroComplex(name, types.Float32)
+		case t.Info()&types.IsComplex != 0:
+			return c.nonZeroComplex(name, types.Float64)
+		}
+
+		return &ast.BinaryExpr{
+			X:  name,
+			Op: token.NEQ,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "0",
+			},
+		}
+	case *types.Slice, *types.Map:
+		if c.keepNil {
+			return &ast.BinaryExpr{
+				X:  name,
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			}
+		}
+
+		return &ast.BinaryExpr{
+			X: &ast.CallExpr{
+				Fun:  ast.NewIdent("len"),
+				Args: []ast.Expr{name},
+			},
+			Op: token.NEQ,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "0",
+			},
+		}
+	case *types.Pointer, *types.Interface:
+		if _, ok := typ.(*types.TypeParam); !ok {
+			return &ast.BinaryExpr{
+				X:  name,
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			}
+		}
+	}
+
+	c.helpers["_is_zero"] = true
+
+	return &ast.UnaryExpr{
+		Op: token.NOT,
+		X: &ast.CallExpr{
+			Fun:  ast.NewIdent("_is_zero"),
+			Args: []ast.Expr{name},
+		},
+	}
+}
+
+func (c *constructor) nonZeroFloat(name ast.Expr, kind types.BasicKind) ast.Expr {
+	c.use("math")
+
+	fn := "Float64bits"
+	if kind == types.Float32 {
+		fn = "Float32bits"
+	}
+
+	return &ast.BinaryExpr{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("math"),
+				Sel: ast.NewIdent(fn),
+			},
+			Args: []ast.Expr{name},
+		},
+		Op: token.NEQ,
+		Y: &ast.BasicLit{
+			Kind:  token.INT,
+			Value: "0",
+		},
+	}
+}
+
+func (c *constructor) nonZeroComplex(name ast.Expr, kind types.BasicKind) ast.Expr {
+	return &ast.BinaryExpr{
+		X: c.nonZeroFloat(&ast.CallExpr{
+			Fun:  ast.NewIdent("real"),
+			Args: []ast.Expr{name},
+		}, kind),
+		Op: token.LOR,
+		Y: c.nonZeroFloat(&ast.CallExpr{
+			Fun:  ast.NewIdent("imag"),
+			Args: []ast.Expr{name},
+		}, kind),
+	}
+}
+
+func hasFloat(typ types.Type) bool {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		return t.Info()&(types.IsFloat|types.IsComplex) != 0
+	case *types.Array:
+		return hasFloat(t.Elem())
+	case *types.Struct:
+		for n := range t.NumFields() {
+			if hasFloat(t.Field(n).Type()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func presentBit(bit int) ast.Expr {
+	return &ast.BinaryExpr{
+		X: &ast.BinaryExpr{
+			X: &ast.IndexExpr{
+				X: ast.NewIdent("present"),
+				Index: &ast.BasicLit{
+					Kind:  token.INT,
+					Value: strconv.Itoa(bit / 8),
+				},
+			},
+			Op: token.AND,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: strconv.Itoa(1 << (bit % 8)),
+			},
+		},
+		Op: token.NEQ,
+		Y: &ast.BasicLit{
+			Kind:  token.INT,
+			Value: "0",
+		},
+	}
+}
+
+func presentType(bits int) ast.Expr {
+	return &ast.ArrayType{
+		Len: &ast.BasicLit{
+			Kind:  token.INT,
+			Value: strconv.Itoa((bits + 7) / 8),
+		},
+		Elt: ast.NewIdent("uint8"),
+	}
+}
+
+func (c *constructor) writeSparse(t *types.Struct) {
+	var (
+		bits   int
+		checks []ast.Stmt
+		writes []ast.Stmt
+	)
+
+	c.eachField(t, true, func(field structField, name ast.Expr) {
+		d := c.subConstructor()
+
+		d.writeType(name, field.Type())
+
+		cond := c.nonZero(name, field.Type())
+		if cond == nil {
+			writes = append(writes, d.statements...)
+
+			return
+		}
+
+		checks = append(checks, &ast.IfStmt{
+			Cond: cond,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{
+							&ast.IndexExpr{
+								X: ast.NewIdent("present"),
+								Index: &ast.BasicLit{
+									Kind:  token.INT,
+									Value: strconv.Itoa(bits / 8),
+								},
+							},
+						},
+						Tok: token.OR_ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.BasicLit{
+								Kind:  token.INT,
+								Value: strconv.Itoa(1 << (bits % 8)),
+							},
+						},
+					},
+				},
+			},
+		})
+		writes = append(writes, &ast.IfStmt{
+			Cond: presentBit(bits),
+			Body: &ast.BlockStmt{
+				List: d.statements,
+			},
+		})
+		bits++
+	})
+
+	if bits > 0 {
+		c.addStatement(&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{
+							ast.NewIdent("present"),
+						},
+						Type: presentType(bits),
+					},
+				},
+			},
+		})
+
+		for _, check := range checks {
+			c.addStatement(check)
+		}
+
+		for n := range (bits + 7) / 8 {
+			c.addWriter("WriteUint8", &ast.IndexExpr{
+				X: ast.NewIdent("present"),
+				Index: &ast.BasicLit{
+					Kind:  token.INT,
+					Value: strconv.Itoa(n),
+				},
+			})
+		}
+	}
+
+	for _, write := range writes {
+		c.addStatement(write)
+	}
+}
+
+func (c *constructor) readSparse(t *types.Struct) {
+	var (
+		bits  int
+		conds []ast.Expr
+	)
+
+	c.eachField(t, false, func(field structField, _ ast.Expr) {
+		if !omittable(field.Type()) {
+			return
+		} else if cond := versionCond(field); cond != nil {
+			conds = append(conds, cond)
+		} else {
+			bits++
+		}
+	})
+
+	if len(conds) > 0 {
+		c.readVersionedPresence(bits, conds)
+	} else if bits > 0 {
+		elts := make([]ast.Expr, (bits+7)/8)
+
+		for n := range elts {
+			elts[n] = readCall("ReadUint8")
+		}
+
+		c.addStatement(&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("present"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CompositeLit{
+					Type: presentType(bits),
+					Elts: elts,
+				},
+			},
+		})
+
+		c.unchecked = true
+
+		c.checkReader()
+	}
+
+	bit := 0
+
+	c.eachField(t, false, func(field structField, name ast.Expr) {
+		cond := versionCond(field)
+
+		switch {
+		case !omittable(field.Type()):
+		case len(conds) > 0:
+			cond = andCond(cond, &ast.CallExpr{
+				Fun: ast.NewIdent("_present"),
+				Args: []ast.Expr{
+					&ast.SliceExpr{
+						X: ast.NewIdent("present"),
+					},
+					&ast.UnaryExpr{
+						Op: token.AND,
+						X:  ast.NewIdent("bit"),
+					},
+				},
+			})
+		default:
+			cond = andCond(cond, presentBit(bit))
+			bit++
+		}
+
+		c.readOptional(name, field.Type(), cond)
+		c.checkReader()
+	})
+}
+
+func (c *constructor) readVersionedPresence(bits int, conds []ast.Expr) {
+	c.helpers["_present"] = true
+
+	c.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("bits"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.BasicLit{
+				Kind:  token.INT,
+				Value: strconv.Itoa(bits),
+			},
+		},
+	})
+
+	for _, cond := range conds {
+		c.addStatement(&ast.IfStmt{
+			Cond: cond,
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.IncDecStmt{
+						X:   ast.NewIdent("bits"),
+						Tok: token.INC,
+					},
+				},
+			},
+		})
+	}
+
+	c.addStatement(&ast.DeclStmt{
+		Decl: &ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{
+				&ast.ValueSpec{
+					Names: []*ast.Ident{
+						ast.NewIdent("present"),
+					},
+					Type: presentType(bits + len(conds)),
+				},
+			},
+		},
+	})
+	c.addStatement(&ast.RangeStmt{
+		Key: ast.NewIdent("n"),
+		Tok: token.DEFINE,
+		X: &ast.BinaryExpr{
+			X: &ast.ParenExpr{
+				X: &ast.BinaryExpr{
+					X:  ast.NewIdent("bits"),
+					Op: token.ADD,
+					Y: &ast.BasicLit{
+						Kind:  token.INT,
+						Value: "7",
+					},
+				},
+			},
+			Op: token.QUO,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "8",
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						&ast.IndexExpr{
+							X:     ast.NewIdent("present"),
+							Index: ast.NewIdent("n"),
+						},
+					},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						readCall("ReadUint8"),
+					},
+				},
+			},
+		},
+	})
+
+	c.unchecked = true
+
+	c.checkReader()
+	c.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("bit"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.BasicLit{
+				Kind:  token.INT,
+				Value: "0",
+			},
+		},
+	})
+}
+
+func presentFunc() *ast.FuncDecl {
+	bit := &ast.StarExpr{
+		X: ast.NewIdent("bit"),
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_present"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("present")},
+						Type: &ast.ArrayType{
+							Elt: ast.NewIdent("uint8"),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("bit")},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("int"),
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("bool"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("n"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{bit},
+				},
+				&ast.IncDecStmt{
+					X:   bit,
+					Tok: token.INC,
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.BinaryExpr{
+							X: &ast.BinaryExpr{
+								X: &ast.IndexExpr{
+									X: ast.NewIdent("present"),
+									Index: &ast.BinaryExpr{
+										X:  ast.NewIdent("n"),
+										Op: token.QUO,
+										Y: &ast.BasicLit{
+											Kind:  token.INT,
+											Value: "8",
+										},
+									},
+								},
+								Op: token.AND,
+								Y: &ast.ParenExpr{
+									X: &ast.BinaryExpr{
+										X: &ast.BasicLit{
+											Kind:  token.INT,
+											Value: "1",
+										},
+										Op: token.SHL,
+										Y: &ast.BinaryExpr{
+											X:  ast.NewIdent("n"),
+											Op: token.REM,
+											Y: &ast.BasicLit{
+												Kind:  token.INT,
+												Value: "8",
+											},
+										},
+									},
+								},
+							},
+							Op: token.NEQ,
+							Y: &ast.BasicLit{
+								Kind:  token.INT,
+								Value: "0",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func isZeroFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_is_zero"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("T")},
+						Type:  ast.NewIdent("comparable"),
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("v")},
+						Type:  ast.NewIdent("T"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("bool"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{ast.NewIdent("zero")},
+								Type:  ast.NewIdent("T"),
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.BinaryExpr{
+							X:  ast.NewIdent("v"),
+							Op: token.EQL,
+							Y:  ast.NewIdent("zero"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
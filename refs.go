@@ -0,0 +1,650 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+func (c *constructor) refsArgs(args ...ast.Expr) []ast.Expr {
+	if !c.refs {
+		return args
+	}
+
+	return append(args, ast.NewIdent("p"))
+}
+
+func (c *constructor) newRefsArgs(args ...ast.Expr) []ast.Expr {
+	if !c.refs {
+		return args
+	}
+
+	root := args[0]
+
+	return append(args, &ast.UnaryExpr{
+		Op: token.AND,
+		X: &ast.CompositeLit{
+			Type: ast.NewIdent("_refs"),
+			Elts: []ast.Expr{
+				&ast.KeyValueExpr{
+					Key: ast.NewIdent("ids"),
+					Value: &ast.CompositeLit{
+						Type: &ast.MapType{
+							Key:   ast.NewIdent("any"),
+							Value: ast.NewIdent("uint64"),
+						},
+						Elts: []ast.Expr{
+							&ast.KeyValueExpr{
+								Key: root,
+								Value: &ast.BasicLit{
+									Kind:  token.INT,
+									Value: "1",
+								},
+							},
+						},
+					},
+				},
+				&ast.KeyValueExpr{
+					Key: ast.NewIdent("ptrs"),
+					Value: &ast.CompositeLit{
+						Type: &ast.ArrayType{
+							Elt: ast.NewIdent("any"),
+						},
+						Elts: []ast.Expr{root},
+					},
+				},
+			},
+		},
+	})
+}
+
+func (c *constructor) refsParam(params []*ast.Field) []*ast.Field {
+	if !c.refs {
+		return params
+	}
+
+	return append(params, &ast.Field{
+		Names: []*ast.Ident{
+			ast.NewIdent("p"),
+		},
+		Type: &ast.StarExpr{
+			X: ast.NewIdent("_refs"),
+		},
+	})
+}
+
+func (c *constructor) writeRef(name ast.Expr, body []ast.Stmt) {
+	fn, args := "_write_ref", []ast.Expr{
+		ast.NewIdent("w"),
+		ast.NewIdent("p"),
+		name,
+	}
+
+	if c.sizing {
+		fn, args = "_size_ref", []ast.Expr{
+			ast.NewIdent("p"),
+			name,
+			&ast.UnaryExpr{
+				Op: token.AND,
+				X:  ast.NewIdent("n"),
+			},
+		}
+	}
+
+	c.helpers[fn] = true
+
+	c.addStatement(&ast.IfStmt{
+		Cond: &ast.CallExpr{
+			Fun:  ast.NewIdent(fn),
+			Args: args,
+		},
+		Body: &ast.BlockStmt{
+			List: body,
+		},
+	})
+}
+
+func (c *constructor) readRef(name ast.Expr, t *types.Pointer) {
+	d := c.subConstructor()
+
+	d.readType(&ast.StarExpr{X: name}, t.Elem())
+
+	c.unchecked = c.unchecked || d.unchecked
+
+	c.use("fmt")
+
+	c.helpers["_read_ref"] = true
+	c.helpers["ErrInvalidReference"] = true
+
+	c.addStatement(&ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("ok"),
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: ast.NewIdent("_read_ref"),
+					Args: []ast.Expr{
+						ast.NewIdent("r"),
+						ast.NewIdent("p"),
+						addr(name),
+					},
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						c.decodeError(ast.NewIdent("err")),
+					},
+				},
+			},
+		},
+		Else: &ast.IfStmt{
+			Cond: ast.NewIdent("ok"),
+			Body: &ast.BlockStmt{
+				List: d.statements,
+			},
+		},
+	})
+}
+
+func (c *constructor) refsDecls() []ast.Decl {
+	if !c.refs {
+		return nil
+	}
+
+	decls := []ast.Decl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: ast.NewIdent("_refs"),
+					Type: &ast.StructType{
+						Fields: &ast.FieldList{
+							List: []*ast.Field{
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("ids"),
+									},
+									Type: &ast.MapType{
+										Key:   ast.NewIdent("any"),
+										Value: ast.NewIdent("uint64"),
+									},
+								},
+								{
+									Names: []*ast.Ident{
+										ast.NewIdent("ptrs"),
+									},
+									Type: &ast.ArrayType{
+										Elt: ast.NewIdent("any"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if c.helpers["_write_ref"] {
+		decls = append(decls, writeRefFunc())
+	}
+
+	if c.helpers["_read_ref"] {
+		decls = append(decls, readRefFunc())
+	}
+
+	return decls
+}
+
+func refFuncType(typeParam, constraint, stream string, typeParams []*ast.Field, ptr ast.Expr, results ...ast.Expr) *ast.FuncType {
+	fields := make([]*ast.Field, len(results))
+
+	for n, result := range results {
+		fields[n] = &ast.Field{
+			Type: result,
+		}
+	}
+
+	return &ast.FuncType{
+		TypeParams: &ast.FieldList{
+			List: append(append([]*ast.Field{
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent(typeParam),
+					},
+					Type: &ast.SelectorExpr{
+						X:   ast.NewIdent("byteio"),
+						Sel: ast.NewIdent(constraint),
+					},
+				},
+			}, typeParams...), &ast.Field{
+				Names: []*ast.Ident{
+					ast.NewIdent("T"),
+				},
+				Type: ast.NewIdent("any"),
+			}),
+		},
+		Params: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent(stream),
+					},
+					Type: ast.NewIdent(typeParam),
+				},
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent("p"),
+					},
+					Type: &ast.StarExpr{
+						X: ast.NewIdent("_refs"),
+					},
+				},
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent("ptr"),
+					},
+					Type: ptr,
+				},
+			},
+		},
+		Results: &ast.FieldList{
+			List: fields,
+		},
+	}
+}
+
+func writeID(id ast.Expr) ast.Stmt {
+	return &ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("w"),
+				Sel: ast.NewIdent("WriteUintX"),
+			},
+			Args: []ast.Expr{
+				id,
+			},
+		},
+	}
+}
+
+func writeRefFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_write_ref"),
+		Type: refFuncType("W", "StickyWriter", "w", nil, &ast.StarExpr{
+			X: ast.NewIdent("T"),
+		}, ast.NewIdent("bool")),
+		Body: &ast.BlockStmt{
+			List: refBody(writeID),
+		},
+	}
+}
+
+func refBody(writeID func(ast.Expr) ast.Stmt) []ast.Stmt {
+	return []ast.Stmt{
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent("ptr"),
+				Op: token.EQL,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					writeID(&ast.BasicLit{
+						Kind:  token.INT,
+						Value: "0",
+					}),
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							ast.NewIdent("false"),
+						},
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("id"),
+					ast.NewIdent("ok"),
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.IndexExpr{
+						X: &ast.SelectorExpr{
+							X:   ast.NewIdent("p"),
+							Sel: ast.NewIdent("ids"),
+						},
+						Index: ast.NewIdent("ptr"),
+					},
+				},
+			},
+			Cond: ast.NewIdent("ok"),
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					writeID(ast.NewIdent("id")),
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							ast.NewIdent("false"),
+						},
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.SelectorExpr{
+					X:   ast.NewIdent("p"),
+					Sel: ast.NewIdent("ids"),
+				},
+				Op: token.EQL,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{
+							&ast.SelectorExpr{
+								X:   ast.NewIdent("p"),
+								Sel: ast.NewIdent("ids"),
+							},
+						},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: ast.NewIdent("make"),
+								Args: []ast.Expr{
+									&ast.MapType{
+										Key:   ast.NewIdent("any"),
+										Value: ast.NewIdent("uint64"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("id"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.BinaryExpr{
+					X: &ast.CallExpr{
+						Fun: ast.NewIdent("uint64"),
+						Args: []ast.Expr{
+							&ast.CallExpr{
+								Fun: ast.NewIdent("len"),
+								Args: []ast.Expr{
+									&ast.SelectorExpr{
+										X:   ast.NewIdent("p"),
+										Sel: ast.NewIdent("ids"),
+									},
+								},
+							},
+						},
+					},
+					Op: token.ADD,
+					Y: &ast.BasicLit{
+						Kind:  token.INT,
+						Value: "1",
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				&ast.IndexExpr{
+					X: &ast.SelectorExpr{
+						X:   ast.NewIdent("p"),
+						Sel: ast.NewIdent("ids"),
+					},
+					Index: ast.NewIdent("ptr"),
+				},
+			},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				ast.NewIdent("id"),
+			},
+		},
+		writeID(ast.NewIdent("id")),
+		&ast.ReturnStmt{
+			Results: []ast.Expr{
+				ast.NewIdent("true"),
+			},
+		},
+	}
+}
+
+func readRefFunc() *ast.FuncDecl {
+	ptrs := &ast.SelectorExpr{
+		X:   ast.NewIdent("p"),
+		Sel: ast.NewIdent("ptrs"),
+	}
+	count := &ast.CallExpr{
+		Fun: ast.NewIdent("uint64"),
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  ast.NewIdent("len"),
+				Args: []ast.Expr{ptrs},
+			},
+		},
+	}
+	deref := &ast.StarExpr{
+		X: ast.NewIdent("ptr"),
+	}
+	assign := &ast.AssignStmt{
+		Lhs: []ast.Expr{deref},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: ast.NewIdent("P"),
+				Args: []ast.Expr{
+					ast.NewIdent("v"),
+				},
+			},
+		},
+	}
+	invalid := &ast.ReturnStmt{
+		Results: []ast.Expr{
+			ast.NewIdent("false"),
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("fmt"),
+					Sel: ast.NewIdent("Errorf"),
+				},
+				Args: []ast.Expr{
+					&ast.BasicLit{
+						Kind:  token.STRING,
+						Value: `"%w: %d"`,
+					},
+					ast.NewIdent("ErrInvalidReference"),
+					ast.NewIdent("id"),
+				},
+			},
+		},
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_read_ref"),
+		Type: refFuncType("R", "StickyReader", "r", []*ast.Field{
+			{
+				Names: []*ast.Ident{
+					ast.NewIdent("P"),
+				},
+				Type: &ast.UnaryExpr{
+					Op: token.TILDE,
+					X: &ast.StarExpr{
+						X: ast.NewIdent("T"),
+					},
+				},
+			},
+		}, &ast.StarExpr{
+			X: ast.NewIdent("P"),
+		}, ast.NewIdent("bool"), ast.NewIdent("error")),
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("id"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						readCall("ReadUintX"),
+					},
+				},
+				&ast.SwitchStmt{
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.CaseClause{
+								List: []ast.Expr{
+									&ast.BinaryExpr{
+										X:  ast.NewIdent("id"),
+										Op: token.EQL,
+										Y: &ast.BasicLit{
+											Kind:  token.INT,
+											Value: "0",
+										},
+									},
+								},
+								Body: []ast.Stmt{
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{deref},
+										Tok: token.ASSIGN,
+										Rhs: []ast.Expr{
+											ast.NewIdent("nil"),
+										},
+									},
+								},
+							},
+							&ast.CaseClause{
+								List: []ast.Expr{
+									&ast.BinaryExpr{
+										X:  ast.NewIdent("id"),
+										Op: token.LEQ,
+										Y:  count,
+									},
+								},
+								Body: []ast.Stmt{
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											ast.NewIdent("v"),
+											ast.NewIdent("ok"),
+										},
+										Tok: token.DEFINE,
+										Rhs: []ast.Expr{
+											&ast.TypeAssertExpr{
+												X: &ast.IndexExpr{
+													X: ptrs,
+													Index: &ast.BinaryExpr{
+														X:  ast.NewIdent("id"),
+														Op: token.SUB,
+														Y: &ast.BasicLit{
+															Kind:  token.INT,
+															Value: "1",
+														},
+													},
+												},
+												Type: &ast.StarExpr{
+													X: ast.NewIdent("T"),
+												},
+											},
+										},
+									},
+									&ast.IfStmt{
+										Cond: &ast.UnaryExpr{
+											Op: token.NOT,
+											X:  ast.NewIdent("ok"),
+										},
+										Body: &ast.BlockStmt{
+											List: []ast.Stmt{
+												invalid,
+											},
+										},
+									},
+									assign,
+								},
+							},
+							&ast.CaseClause{
+								List: []ast.Expr{
+									&ast.BinaryExpr{
+										X:  ast.NewIdent("id"),
+										Op: token.EQL,
+										Y: &ast.BinaryExpr{
+											X:  count,
+											Op: token.ADD,
+											Y: &ast.BasicLit{
+												Kind:  token.INT,
+												Value: "1",
+											},
+										},
+									},
+								},
+								Body: []ast.Stmt{
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											ast.NewIdent("v"),
+										},
+										Tok: token.DEFINE,
+										Rhs: []ast.Expr{
+											&ast.CallExpr{
+												Fun: ast.NewIdent("new"),
+												Args: []ast.Expr{
+													ast.NewIdent("T"),
+												},
+											},
+										},
+									},
+									assign,
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{ptrs},
+										Tok: token.ASSIGN,
+										Rhs: []ast.Expr{
+											&ast.CallExpr{
+												Fun: ast.NewIdent("append"),
+												Args: []ast.Expr{
+													ptrs,
+													ast.NewIdent("v"),
+												},
+											},
+										},
+									},
+									&ast.ReturnStmt{
+										Results: []ast.Expr{
+											ast.NewIdent("true"),
+											ast.NewIdent("nil"),
+										},
+									},
+								},
+							},
+							&ast.CaseClause{
+								Body: []ast.Stmt{
+									invalid,
+								},
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("false"),
+						ast.NewIdent("nil"),
+					},
+				},
+			},
+		},
+	}
+}
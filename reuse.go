@@ -0,0 +1,232 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+func (c *constructor) reuseSlice(name, length ast.Expr, kind types.BasicKind) {
+	c.helpers["_reuse_slice"] = true
+
+	c.addStatement(&ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: ast.NewIdent("_reuse_slice"),
+			Args: []ast.Expr{
+				addr(name),
+				convert(length, types.Typ[kind], types.Uint64),
+			},
+		},
+	})
+}
+
+func (c *constructor) reuseMap(name ast.Expr) {
+	c.helpers["_reuse_map"] = true
+
+	c.addStatement(&ast.ExprStmt{
+		X: &ast.CallExpr{
+			Fun: ast.NewIdent("_reuse_map"),
+			Args: []ast.Expr{
+				addr(name),
+			},
+		},
+	})
+}
+
+func (c *constructor) reuseDecls() []ast.Decl {
+	var decls []ast.Decl
+
+	if c.helpers["_reuse_slice"] {
+		decls = append(decls, reuseSlice())
+	}
+
+	if c.helpers["_reuse_map"] {
+		decls = append(decls, reuseMap())
+	}
+
+	return decls
+}
+
+func reuseSlice() *ast.FuncDecl {
+	ptr := &ast.StarExpr{
+		X: ast.NewIdent("ptr"),
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_reuse_slice"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("S")},
+						Type: &ast.UnaryExpr{
+							Op: token.TILDE,
+							X: &ast.ArrayType{
+								Elt: ast.NewIdent("T"),
+							},
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("T")},
+						Type:  ast.NewIdent("any"),
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("ptr")},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("S"),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("l")},
+						Type:  ast.NewIdent("uint64"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IfStmt{
+					Cond: &ast.BinaryExpr{
+						X: &ast.BinaryExpr{
+							X:  ptr,
+							Op: token.NEQ,
+							Y:  ast.NewIdent("nil"),
+						},
+						Op: token.LAND,
+						Y: &ast.BinaryExpr{
+							X: &ast.CallExpr{
+								Fun: ast.NewIdent("uint64"),
+								Args: []ast.Expr{
+									&ast.CallExpr{
+										Fun:  ast.NewIdent("cap"),
+										Args: []ast.Expr{ptr},
+									},
+								},
+							},
+							Op: token.GEQ,
+							Y:  ast.NewIdent("l"),
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.AssignStmt{
+								Lhs: []ast.Expr{ptr},
+								Tok: token.ASSIGN,
+								Rhs: []ast.Expr{
+									&ast.SliceExpr{
+										X: &ast.ParenExpr{
+											X: ptr,
+										},
+										High: ast.NewIdent("l"),
+									},
+								},
+							},
+						},
+					},
+					Else: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.AssignStmt{
+								Lhs: []ast.Expr{ptr},
+								Tok: token.ASSIGN,
+								Rhs: []ast.Expr{
+									&ast.CallExpr{
+										Fun: ast.NewIdent("make"),
+										Args: []ast.Expr{
+											ast.NewIdent("S"),
+											ast.NewIdent("l"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func reuseMap() *ast.FuncDecl {
+	ptr := &ast.StarExpr{
+		X: ast.NewIdent("ptr"),
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_reuse_map"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("M")},
+						Type: &ast.UnaryExpr{
+							Op: token.TILDE,
+							X: &ast.MapType{
+								Key:   ast.NewIdent("K"),
+								Value: ast.NewIdent("V"),
+							},
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("K")},
+						Type:  ast.NewIdent("comparable"),
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("V")},
+						Type:  ast.NewIdent("any"),
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("ptr")},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("M"),
+						},
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.IfStmt{
+					Cond: &ast.BinaryExpr{
+						X:  ptr,
+						Op: token.EQL,
+						Y:  ast.NewIdent("nil"),
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.AssignStmt{
+								Lhs: []ast.Expr{ptr},
+								Tok: token.ASSIGN,
+								Rhs: []ast.Expr{
+									&ast.CallExpr{
+										Fun: ast.NewIdent("make"),
+										Args: []ast.Expr{
+											ast.NewIdent("M"),
+										},
+									},
+								},
+							},
+						},
+					},
+					Else: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ExprStmt{
+								X: &ast.CallExpr{
+									Fun:  ast.NewIdent("clear"),
+									Args: []ast.Expr{ptr},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
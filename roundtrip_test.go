@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	generateEnv   = "MARSHAL_TEST_GENERATE"
+	generateLine  = "//go:generate marshal "
+	byteioModule  = "vimagination.zapto.org/byteio"
+	byteioVersion = "v1.3.2"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(generateEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("round trip tests build generated packages")
+	}
+
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+
+	download := exec.Command("go", "mod", "download", byteioModule+"@"+byteioVersion)
+	download.Env = offlineEnv()
+
+	if out, err := download.CombinedOutput(); err != nil {
+		t.Skipf("%s@%s is not in the module cache: %s", byteioModule, byteioVersion, bytes.TrimSpace(out))
+	}
+
+	fixtures, err := os.ReadDir(filepath.Join("testdata", "roundtrip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.Name(), func(t *testing.T) {
+			t.Parallel()
+			roundTrip(t, fixture.Name())
+		})
+	}
+}
+
+func offlineEnv() []string {
+	return append(os.Environ(), "GOPROXY=off", "GOSUMDB=off", "GOFLAGS=-mod=mod")
+}
+
+func roundTrip(t *testing.T, fixture string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	args := copyFixture(t, filepath.Join("testdata", "roundtrip", fixture), dir)
+
+	if args == nil {
+		t.Fatalf("no %q line in fixture", generateLine)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module roundtrip\n\ngo 1.25\n\nrequire "+byteioModule+" "+byteioVersion+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := generate(dir, args...); err != nil {
+		t.Fatalf("generating code: %s\n%s", err, out)
+	}
+
+	test := exec.Command("go", "test", ".")
+	test.Dir = dir
+	test.Env = offlineEnv()
+
+	if out, err := test.CombinedOutput(); err != nil {
+		t.Fatalf("testing generated code: %s\n%s", err, out)
+	}
+}
+
+func copyFixture(t *testing.T, src, dst string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var args []string
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for s := bufio.NewScanner(bytes.NewReader(data)); s.Scan(); {
+			if line, ok := strings.CutPrefix(s.Text(), generateLine); ok {
+				args = strings.Fields(line)
+			}
+		}
+
+		if err := os.WriteFile(filepath.Join(dst, entry.Name()), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return args
+}
+
+func generate(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), generateEnv+"=1")
+
+	return cmd.CombinedOutput()
+}
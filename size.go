@@ -0,0 +1,735 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+func sizeName(funcName string) string {
+	return "_size_" + strings.TrimPrefix(funcName, "_marshal_")
+}
+
+func (c *constructor) addSize(size ast.Expr) {
+	if lit, ok := size.(*ast.BasicLit); ok && lit.Kind == token.INT {
+		if lit.Value == "0" {
+			return
+		} else if l := len(c.statements); l > 0 {
+			if prev, ok := c.statements[l-1].(*ast.AssignStmt); ok && prev.Tok == token.ADD_ASSIGN {
+				if sum, ok := prev.Rhs[0].(*ast.BasicLit); ok && sum.Kind == token.INT {
+					a, _ := strconv.ParseInt(sum.Value, 10, 64)
+					b, _ := strconv.ParseInt(lit.Value, 10, 64)
+					prev.Rhs[0] = intLit(a + b)
+
+					return
+				}
+			}
+		}
+	}
+
+	c.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("n"),
+		},
+		Tok: token.ADD_ASSIGN,
+		Rhs: []ast.Expr{
+			size,
+		},
+	})
+}
+
+func (c *constructor) writerSize(method string, name ast.Expr) ast.Expr {
+	switch method {
+	case "Write":
+		return lenCall(name)
+	case "WriteBool", "WriteInt8", "WriteUint8":
+		return intLit(1)
+	case "WriteInt16", "WriteUint16":
+		return intLit(2)
+	case "WriteInt32", "WriteUint32", "WriteFloat32":
+		return intLit(4)
+	case "WriteInt64", "WriteUint64", "WriteFloat64":
+		return intLit(8)
+	case "WriteUintX":
+		return c.uintxSize(name)
+	case "WriteIntX":
+		c.helpers["_size_intx"] = true
+
+		return &ast.CallExpr{
+			Fun:  ast.NewIdent("_size_intx"),
+			Args: []ast.Expr{name},
+		}
+	case "WriteStringX":
+		return &ast.BinaryExpr{
+			X: c.uintxSize(&ast.CallExpr{
+				Fun:  ast.NewIdent("uint64"),
+				Args: []ast.Expr{lenCall(name)},
+			}),
+			Op: token.ADD,
+			Y:  lenCall(name),
+		}
+	}
+
+	bits, _ := strconv.Atoi(strings.TrimPrefix(method, "WriteString"))
+
+	return &ast.BinaryExpr{
+		X:  intLit(int64(bits / 8)),
+		Op: token.ADD,
+		Y:  lenCall(name),
+	}
+}
+
+func (c *constructor) uintxSize(name ast.Expr) ast.Expr {
+	if lit, ok := name.(*ast.BasicLit); ok && lit.Kind == token.INT {
+		if v, err := strconv.ParseUint(lit.Value, 10, 64); err == nil && v < 0x80 {
+			return intLit(1)
+		}
+	}
+
+	c.helpers["_size_uintx"] = true
+
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent("_size_uintx"),
+		Args: []ast.Expr{name},
+	}
+}
+
+func lenCall(name ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent("len"),
+		Args: []ast.Expr{name},
+	}
+}
+
+func mulSize(length ast.Expr, size int64) ast.Expr {
+	if size == 1 {
+		return length
+	}
+
+	return &ast.BinaryExpr{
+		X:  length,
+		Op: token.MUL,
+		Y:  intLit(size),
+	}
+}
+
+func (c *constructor) sizeMapEntries(name ast.Expr, t *types.Map) {
+	d := c.subConstructor()
+	k := c.varName("k")
+	v := c.varName("v")
+	path := c.path
+
+	var fixed int64
+
+	c.writeLength(name)
+
+	if size, ok := c.fixedSize(t.Key()); ok {
+		fixed += size
+	} else {
+		d.path = path + "[key]"
+
+		d.writeType(k, t.Key())
+	}
+
+	if size, ok := c.fixedSize(t.Elem()); ok {
+		fixed += size
+	} else {
+		d.path = path + "[]"
+
+		d.writeType(v, t.Elem())
+	}
+
+	if fixed > 0 {
+		c.addSize(mulSize(lenCall(name), fixed))
+	}
+
+	if len(d.statements) == 0 {
+		return
+	} else if !c.sortKeys || !c.refs {
+		c.addRange(&ast.RangeStmt{
+			For:   c.newLine(),
+			Key:   k,
+			Value: v,
+			Tok:   token.DEFINE,
+			X:     name,
+			Body: &ast.BlockStmt{
+				List: d.statements,
+			},
+		})
+
+		return
+	}
+
+	if uses(d.statements, v) {
+		d.statements = append([]ast.Stmt{
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{
+					v,
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.IndexExpr{
+						X:     name,
+						Index: k,
+					},
+				},
+			},
+		}, d.statements...)
+	}
+
+	c.addRange(&ast.RangeStmt{
+		For:   c.newLine(),
+		Key:   ast.NewIdent("_"),
+		Value: k,
+		Tok:   token.DEFINE,
+		X:     c.sortedKeys(name, t.Key()),
+		Body: &ast.BlockStmt{
+			List: d.statements,
+		},
+	})
+}
+
+func (c *constructor) addRange(stmt *ast.RangeStmt) {
+	if len(stmt.Body.List) == 0 {
+		return
+	} else if c.sizing {
+		if ident, ok := stmt.Value.(*ast.Ident); ok && !uses(stmt.Body.List, ident) {
+			stmt.Value = nil
+		}
+
+		if ident, ok := stmt.Key.(*ast.Ident); ok && (ident.Name == "_" || !uses(stmt.Body.List, ident)) {
+			stmt.Key = ast.NewIdent("_")
+
+			if stmt.Value == nil {
+				stmt.Key = nil
+				stmt.Tok = token.ILLEGAL
+			}
+		}
+	}
+
+	c.addStatement(stmt)
+}
+
+func uses(stmts []ast.Stmt, name *ast.Ident) bool {
+	var found bool
+
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Ident:
+				found = found || n.Name == name.Name
+			case *ast.SelectorExpr:
+				found = found || uses([]ast.Stmt{&ast.ExprStmt{X: n.X}}, name)
+
+				return false
+			}
+
+			return !found
+		})
+	}
+
+	return found
+}
+
+func (c *constructor) sizeTagged(number int, body []ast.Stmt) ast.Stmt {
+	c.helpers["_size_field"] = true
+
+	var size ast.Expr = &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Params: &ast.FieldList{},
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{
+								ast.NewIdent("n"),
+							},
+							Type: ast.NewIdent("int"),
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: append(body, &ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("n"),
+					},
+				}),
+			},
+		},
+	}
+
+	if len(body) == 0 {
+		size = intLit(0)
+	} else if add, ok := body[0].(*ast.AssignStmt); ok && len(body) == 1 && add.Tok == token.ADD_ASSIGN {
+		size = add.Rhs[0]
+	}
+
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("n"),
+		},
+		Tok: token.ADD_ASSIGN,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: ast.NewIdent("_size_field"),
+				Args: []ast.Expr{
+					intLit(int64(number)),
+					size,
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) sizeFunc(typ *types.Named) *ast.FuncDecl {
+	d := c.subConstructor()
+	d.depth = c.depth
+	d.sizing = true
+	d.path = typ.Obj().Name()
+
+	d.marshalBody(typ)
+
+	params, _ := c.typeParams(typ, "")
+
+	return c.sizeDecl(sizeName(marshalName(typ)), params, &ast.StarExpr{
+		X: c.accessibleIdent(typ),
+	}, d.statements)
+}
+
+func (c *constructor) sizeCall(typ *types.Named) ast.Expr {
+	if size, ok := c.fixedSize(typ); ok {
+		return intLit(size)
+	}
+
+	return &ast.CallExpr{
+		Fun:  ast.NewIdent(sizeName(marshalName(typ))),
+		Args: c.newRefsArgs(ast.NewIdent("t")),
+	}
+}
+
+func (c *constructor) sizeDecl(name string, typeParams []*ast.Field, typ ast.Expr, body []ast.Stmt) *ast.FuncDecl {
+	var tparams *ast.FieldList
+
+	if len(typeParams) > 0 {
+		tparams = &ast.FieldList{
+			List: typeParams,
+		}
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(name),
+		Type: &ast.FuncType{
+			Func:       c.newLine(),
+			TypeParams: tparams,
+			Params: &ast.FieldList{
+				List: c.refsParam([]*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("t"),
+						},
+						Type: typ,
+					},
+				}),
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("n"),
+						},
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: append(body, &ast.ReturnStmt{
+				Return: c.newLine(),
+				Results: []ast.Expr{
+					ast.NewIdent("n"),
+				},
+			}),
+		},
+	}
+}
+
+func (c *constructor) sizeDecls() []ast.Decl {
+	var decls []ast.Decl
+
+	if c.helpers["_size_time_Time"] {
+		decls = append(decls, c.sizeTime())
+	}
+
+	if c.helpers["_size_big_Int"] {
+		decls = append(decls, c.sizeBigInt())
+	}
+
+	if c.helpers["_size_any"] {
+		decls = append(decls, c.sizeAny())
+	}
+
+	if c.helpers["_size_binary"] {
+		decls = append(decls, c.sizeBinary("_size_binary", "MarshalBinary", "BinaryMarshaler"))
+	}
+
+	if c.helpers["_size_appender"] {
+		decls = append(decls, c.sizeBinary("_size_appender", "AppendBinary", "BinaryAppender"))
+	}
+
+	if c.helpers["_size_field"] {
+		decls = append(decls, c.sizeField())
+	}
+
+	if c.helpers["_size_ref"] {
+		decls = append(decls, c.sizeRefFunc())
+	}
+
+	if c.helpers["_size_intx"] {
+		decls = append(decls, c.varintSize("_size_intx", "int64", "WriteIntX"))
+	}
+
+	if c.helpers["_size_uintx"] {
+		decls = append(decls, c.varintSize("_size_uintx", "uint64", "WriteUintX"))
+	}
+
+	return decls
+}
+
+func (c *constructor) sizeTime() *ast.FuncDecl {
+	d := c.subConstructor()
+	d.sizing = true
+
+	d.writeTime()
+
+	return c.sizeDecl("_size_time_Time", nil, &ast.StarExpr{
+		X: &ast.SelectorExpr{
+			X:   ast.NewIdent("time"),
+			Sel: ast.NewIdent("Time"),
+		},
+	}, d.statements)
+}
+
+func (c *constructor) sizeBigInt() *ast.FuncDecl {
+	d := c.subConstructor()
+	d.sizing = true
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("l"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.BinaryExpr{
+				X: &ast.ParenExpr{
+					X: &ast.BinaryExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent("t"),
+								Sel: ast.NewIdent("BitLen"),
+							},
+						},
+						Op: token.ADD,
+						Y:  intLit(7),
+					},
+				},
+				Op: token.QUO,
+				Y:  intLit(8),
+			},
+		},
+	})
+	d.addSize(intLit(1))
+	d.addSize(&ast.BinaryExpr{
+		X: d.uintxSize(&ast.CallExpr{
+			Fun: ast.NewIdent("uint64"),
+			Args: []ast.Expr{
+				ast.NewIdent("l"),
+			},
+		}),
+		Op: token.ADD,
+		Y:  ast.NewIdent("l"),
+	})
+
+	return c.sizeDecl("_size_big_Int", nil, &ast.StarExpr{
+		X: &ast.SelectorExpr{
+			X:   ast.NewIdent("big"),
+			Sel: ast.NewIdent("Int"),
+		},
+	}, d.statements)
+}
+
+func (c *constructor) sizeBinary(name, method, iface string) *ast.FuncDecl {
+	d := c.subConstructor()
+	d.sizing = true
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("b"),
+			ast.NewIdent("_"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			marshalCall(method),
+		},
+	})
+	d.writeBytes(ast.NewIdent("b"))
+
+	return c.sizeDecl(name, []*ast.Field{
+		{
+			Names: []*ast.Ident{
+				ast.NewIdent("T"),
+			},
+			Type: &ast.SelectorExpr{
+				X:   ast.NewIdent("encoding"),
+				Sel: ast.NewIdent(iface),
+			},
+		},
+	}, ast.NewIdent("T"), d.statements)
+}
+
+func (c *constructor) sizeAny() *ast.FuncDecl {
+	c.helpers["_size_binary"] = true
+	c.helpers["_size_appender"] = true
+
+	return c.sizeDecl("_size_any", nil, ast.NewIdent("any"), []ast.Stmt{
+		&ast.TypeSwitchStmt{
+			Assign: &ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("t"),
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.TypeAssertExpr{
+						X: ast.NewIdent("t"),
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					anyCase("BinaryMarshaler", "_size_binary", c.refsArgs(ast.NewIdent("t"))...),
+					anyCase("BinaryAppender", "_size_appender", c.refsArgs(ast.NewIdent("t"))...),
+				},
+			},
+		},
+	})
+}
+
+func (c *constructor) sizeField() *ast.FuncDecl {
+	c.helpers["_size_uintx"] = true
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_size_field"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("tag"),
+						},
+						Type: ast.NewIdent("uint64"),
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("size"),
+						},
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.BinaryExpr{
+							X: &ast.BinaryExpr{
+								X: &ast.CallExpr{
+									Fun: ast.NewIdent("_size_uintx"),
+									Args: []ast.Expr{
+										ast.NewIdent("tag"),
+									},
+								},
+								Op: token.ADD,
+								Y: &ast.CallExpr{
+									Fun: ast.NewIdent("_size_uintx"),
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun: ast.NewIdent("uint64"),
+											Args: []ast.Expr{
+												ast.NewIdent("size"),
+											},
+										},
+									},
+								},
+							},
+							Op: token.ADD,
+							Y:  ast.NewIdent("size"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) sizeRefFunc() *ast.FuncDecl {
+	c.helpers["_size_uintx"] = true
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_size_ref"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("T"),
+						},
+						Type: ast.NewIdent("any"),
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("p"),
+						},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("_refs"),
+						},
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("ptr"),
+						},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("T"),
+						},
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("n"),
+						},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("int"),
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("bool"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: refBody(func(id ast.Expr) ast.Stmt {
+				return &ast.AssignStmt{
+					Lhs: []ast.Expr{
+						&ast.StarExpr{
+							X: ast.NewIdent("n"),
+						},
+					},
+					Tok: token.ADD_ASSIGN,
+					Rhs: []ast.Expr{
+						c.uintxSize(id),
+					},
+				}
+			}),
+		},
+	}
+}
+
+func (c *constructor) varintSize(name, typ, method string) *ast.FuncDecl {
+	mem := &ast.SelectorExpr{
+		X:   ast.NewIdent("byteio"),
+		Sel: ast.NewIdent("Mem" + c.endian()),
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(name),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("v"),
+						},
+						Type: ast.NewIdent(typ),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("int"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{
+									ast.NewIdent("buf"),
+								},
+								Type: &ast.ArrayType{
+									Len: intLit(10),
+									Elt: ast.NewIdent("byte"),
+								},
+							},
+						},
+					},
+				},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: mem,
+							Args: []ast.Expr{
+								&ast.SliceExpr{
+									X:    ast.NewIdent("buf"),
+									High: intLit(0),
+								},
+							},
+						},
+					},
+				},
+				&ast.ExprStmt{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("b"),
+							Sel: ast.NewIdent(method),
+						},
+						Args: []ast.Expr{
+							ast.NewIdent("v"),
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						lenCall(ast.NewIdent("b")),
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,819 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+)
+
+var (
+	stdlibTypes = map[string]string{
+		"time.Time":    "time_Time",
+		"math/big.Int": "big_Int",
+	}
+
+	byteSlice = types.NewSlice(types.Typ[types.Byte])
+	errorType = types.Universe.Lookup("error").Type()
+
+	binaryMarshaler   = binaryInterface("MarshalBinary", types.NewTuple(), types.NewTuple(types.NewParam(token.NoPos, nil, "", byteSlice), types.NewParam(token.NoPos, nil, "", errorType)))
+	binaryAppender    = binaryInterface("AppendBinary", types.NewTuple(types.NewParam(token.NoPos, nil, "", byteSlice)), types.NewTuple(types.NewParam(token.NoPos, nil, "", byteSlice), types.NewParam(token.NoPos, nil, "", errorType)))
+	binaryUnmarshaler = binaryInterface("UnmarshalBinary", types.NewTuple(types.NewParam(token.NoPos, nil, "", byteSlice)), types.NewTuple(types.NewParam(token.NoPos, nil, "", errorType)))
+)
+
+func binaryInterface(method string, params, results *types.Tuple) *types.Interface {
+	return types.NewInterfaceType([]*types.Func{
+		types.NewFunc(token.NoPos, nil, method, types.NewSignatureType(nil, nil, nil, params, results, false)),
+	}, nil).Complete()
+}
+
+func stdlibFunc(typ *types.Named) string {
+	if typ.Obj().Pkg() == nil {
+		return ""
+	}
+
+	return stdlibTypes[typ.Obj().Pkg().Path()+"."+typ.Obj().Name()]
+}
+
+func binaryFunc(typ *types.Named, marshal bool) string {
+	ptr := types.NewPointer(typ)
+
+	if !types.Implements(ptr, binaryUnmarshaler) || !declared(ptr, "UnmarshalBinary") {
+		return ""
+	}
+
+	switch {
+	case types.Implements(ptr, binaryMarshaler) && declared(ptr, "MarshalBinary"):
+		if marshal {
+			return "_marshal_binary"
+		}
+	case types.Implements(ptr, binaryAppender) && declared(ptr, "AppendBinary"):
+		if marshal {
+			return "_marshal_appender"
+		}
+	default:
+		return ""
+	}
+
+	return "_unmarshal_binary"
+}
+
+func declared(typ types.Type, method string) bool {
+	_, index, _ := types.LookupFieldOrMethod(typ, false, nil, method)
+
+	return len(index) == 1
+}
+
+func (c *constructor) callHelper(funcName, stream string, name ast.Expr) {
+	if c.sizing {
+		c.helpers[sizeName(funcName)] = true
+	} else {
+		c.helpers[funcName] = true
+	}
+
+	c.callFunc(funcName, stream, name)
+}
+
+func (c *constructor) helperDecls() []ast.Decl {
+	var decls []ast.Decl
+
+	for _, name := range slices.Sorted(maps.Keys(c.helpers)) {
+		switch name {
+		case "_marshal_time_Time":
+			decls = append(decls, c.marshalTime())
+		case "_unmarshal_time_Time":
+			decls = append(decls, c.unmarshalTime())
+			decls = append(decls, c.locationDecls()...)
+		case "_marshal_big_Int":
+			decls = append(decls, c.marshalBigInt())
+		case "_unmarshal_big_Int":
+			decls = append(decls, c.unmarshalBigInt())
+		case "_marshal_binary":
+			decls = append(decls, c.marshalBinaryHelper("_marshal_binary", "MarshalBinary", "BinaryMarshaler"))
+		case "_marshal_appender":
+			decls = append(decls, c.marshalBinaryHelper("_marshal_appender", "AppendBinary", "BinaryAppender"))
+		case "_unmarshal_binary":
+			decls = append(decls, c.unmarshalBinaryHelper())
+		case "_marshal_any":
+			decls = append(decls, c.marshalAny())
+		case "_unmarshal_any":
+			decls = append(decls, c.unmarshalAny())
+		case "_fixed_bool":
+			decls = append(decls, fixedBool())
+		case "ErrTrailingBytes":
+			decls = append(decls, c.errorVar(name, "is returned by strict decoders when bytes remain after decoding.", "trailing bytes after decoded value"))
+		case "ErrUnsupportedVersion":
+			decls = append(decls, c.errorVar(name, "is returned when decoding data written with an unknown version.", "unsupported version"))
+		case "ErrInvalidReference":
+			decls = append(decls, c.errorVar(name, "is returned when decoding a reference to an object that has not been decoded.", "invalid reference"))
+		case "DecodeError":
+			decls = append(decls, c.decodeErrorDecls()...)
+		case "_zero":
+			decls = append(decls, zeroFunc())
+		case "_write_field":
+			decls = append(decls, writeFieldFunc())
+		case "_read_field":
+			decls = append(decls, readFieldFunc())
+		case "_is_zero":
+			decls = append(decls, isZeroFunc())
+		case "_present":
+			decls = append(decls, presentFunc())
+		}
+	}
+
+	return decls
+}
+
+func (c *constructor) marshalTime() *ast.FuncDecl {
+	c.use("time")
+
+	d := c.subConstructor()
+
+	d.writeTime()
+
+	return c.streamFunc("_marshal_time_Time", true, nil, "W", &ast.SelectorExpr{
+		X:   ast.NewIdent("time"),
+		Sel: ast.NewIdent("Time"),
+	}, append(d.statements, returnNil()))
+}
+
+func (c *constructor) writeTime() {
+	if !c.sizing {
+		c.addStatement(&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("_"),
+				ast.NewIdent("offset"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent("t"),
+						Sel: ast.NewIdent("Zone"),
+					},
+				},
+			},
+		})
+	}
+
+	c.addWriter("WriteInt64", &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("t"),
+			Sel: ast.NewIdent("Unix"),
+		},
+	})
+	c.addWriter("WriteUint32", &ast.CallExpr{
+		Fun: ast.NewIdent("uint32"),
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("t"),
+					Sel: ast.NewIdent("Nanosecond"),
+				},
+			},
+		},
+	})
+	c.addWriter("WriteStringX", &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("t"),
+					Sel: ast.NewIdent("Location"),
+				},
+			},
+			Sel: ast.NewIdent("String"),
+		},
+	})
+	c.addWriter("WriteInt32", &ast.CallExpr{
+		Fun: ast.NewIdent("int32"),
+		Args: []ast.Expr{
+			ast.NewIdent("offset"),
+		},
+	})
+}
+
+func (c *constructor) unmarshalTime() *ast.FuncDecl {
+	c.use("time")
+
+	d := c.subConstructor()
+	d.lenWidth = 0
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("sec"),
+			ast.NewIdent("nsec"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			readCall("ReadInt64"),
+			readCall("ReadUint32"),
+		},
+	})
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("name"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			d.stringExpr(),
+		},
+	})
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("offset"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			readCall("ReadInt32"),
+		},
+	})
+
+	return c.streamFunc("_unmarshal_time_Time", false, nil, "R", &ast.SelectorExpr{
+		X:   ast.NewIdent("time"),
+		Sel: ast.NewIdent("Time"),
+	}, append(d.statements,
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("loc"),
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: ast.NewIdent("_load_location"),
+					Args: []ast.Expr{
+						ast.NewIdent("name"),
+					},
+				},
+			},
+		},
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X:  ast.NewIdent("name"),
+					Op: token.EQL,
+					Y: &ast.BasicLit{
+						Kind:  token.STRING,
+						Value: `""`,
+					},
+				},
+				Op: token.LOR,
+				Y: &ast.BinaryExpr{
+					X:  ast.NewIdent("err"),
+					Op: token.NEQ,
+					Y:  ast.NewIdent("nil"),
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("loc"),
+						},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   ast.NewIdent("time"),
+									Sel: ast.NewIdent("FixedZone"),
+								},
+								Args: []ast.Expr{
+									ast.NewIdent("name"),
+									&ast.CallExpr{
+										Fun: ast.NewIdent("int"),
+										Args: []ast.Expr{
+											ast.NewIdent("offset"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				&ast.StarExpr{
+					X: ast.NewIdent("t"),
+				},
+			},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X: &ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent("time"),
+								Sel: ast.NewIdent("Unix"),
+							},
+							Args: []ast.Expr{
+								ast.NewIdent("sec"),
+								&ast.CallExpr{
+									Fun: ast.NewIdent("int64"),
+									Args: []ast.Expr{
+										ast.NewIdent("nsec"),
+									},
+								},
+							},
+						},
+						Sel: ast.NewIdent("In"),
+					},
+					Args: []ast.Expr{
+						ast.NewIdent("loc"),
+					},
+				},
+			},
+		},
+		returnNil(),
+	))
+}
+
+func (c *constructor) locationDecls() []ast.Decl {
+	c.use("sync")
+
+	return []ast.Decl{
+		&ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{
+				&ast.ValueSpec{
+					Names: []*ast.Ident{
+						ast.NewIdent("_locations"),
+					},
+					Type: &ast.SelectorExpr{
+						X:   ast.NewIdent("sync"),
+						Sel: ast.NewIdent("Map"),
+					},
+				},
+			},
+		},
+		&ast.FuncDecl{
+			Name: ast.NewIdent("_load_location"),
+			Type: &ast.FuncType{
+				Params: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{
+								ast.NewIdent("name"),
+							},
+							Type: ast.NewIdent("string"),
+						},
+					},
+				},
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Type: &ast.StarExpr{
+								X: &ast.SelectorExpr{
+									X:   ast.NewIdent("time"),
+									Sel: ast.NewIdent("Location"),
+								},
+							},
+						},
+						{
+							Type: ast.NewIdent("error"),
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.IfStmt{
+						Init: &ast.AssignStmt{
+							Lhs: []ast.Expr{
+								ast.NewIdent("loc"),
+								ast.NewIdent("ok"),
+							},
+							Tok: token.DEFINE,
+							Rhs: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   ast.NewIdent("_locations"),
+										Sel: ast.NewIdent("Load"),
+									},
+									Args: []ast.Expr{
+										ast.NewIdent("name"),
+									},
+								},
+							},
+						},
+						Cond: ast.NewIdent("ok"),
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.ReturnStmt{
+									Results: []ast.Expr{
+										&ast.TypeAssertExpr{
+											X: ast.NewIdent("loc"),
+											Type: &ast.StarExpr{
+												X: &ast.SelectorExpr{
+													X:   ast.NewIdent("time"),
+													Sel: ast.NewIdent("Location"),
+												},
+											},
+										},
+										ast.NewIdent("nil"),
+									},
+								},
+							},
+						},
+					},
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("loc"),
+							ast.NewIdent("err"),
+						},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   ast.NewIdent("time"),
+									Sel: ast.NewIdent("LoadLocation"),
+								},
+								Args: []ast.Expr{
+									ast.NewIdent("name"),
+								},
+							},
+						},
+					},
+					&ast.IfStmt{
+						Cond: &ast.BinaryExpr{
+							X:  ast.NewIdent("err"),
+							Op: token.EQL,
+							Y:  ast.NewIdent("nil"),
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.ExprStmt{
+									X: &ast.CallExpr{
+										Fun: &ast.SelectorExpr{
+											X:   ast.NewIdent("_locations"),
+											Sel: ast.NewIdent("Store"),
+										},
+										Args: []ast.Expr{
+											ast.NewIdent("name"),
+											ast.NewIdent("loc"),
+										},
+									},
+								},
+							},
+						},
+					},
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							ast.NewIdent("loc"),
+							ast.NewIdent("err"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (c *constructor) marshalBigInt() *ast.FuncDecl {
+	c.use("math/big")
+
+	d := c.subConstructor()
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("b"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("t"),
+					Sel: ast.NewIdent("Bytes"),
+				},
+			},
+		},
+	})
+	d.addWriter("WriteInt8", &ast.CallExpr{
+		Fun: ast.NewIdent("int8"),
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("t"),
+					Sel: ast.NewIdent("Sign"),
+				},
+			},
+		},
+	})
+	d.writeBytes(ast.NewIdent("b"))
+
+	return c.streamFunc("_marshal_big_Int", true, nil, "W", &ast.SelectorExpr{
+		X:   ast.NewIdent("big"),
+		Sel: ast.NewIdent("Int"),
+	}, append(d.statements, returnNil()))
+}
+
+func (c *constructor) unmarshalBigInt() *ast.FuncDecl {
+	c.use("math/big")
+
+	d := c.subConstructor()
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("sign"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			readCall("ReadInt8"),
+		},
+	})
+	d.readBytes(ast.NewIdent("b"))
+	d.addCall(&ast.SelectorExpr{
+		X:   ast.NewIdent("t"),
+		Sel: ast.NewIdent("SetBytes"),
+	}, ast.NewIdent("b"))
+	d.addStatement(&ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("sign"),
+			Op: token.LSS,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "0",
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ExprStmt{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("t"),
+							Sel: ast.NewIdent("Neg"),
+						},
+						Args: []ast.Expr{
+							ast.NewIdent("t"),
+						},
+					},
+				},
+			},
+		},
+	})
+
+	return c.streamFunc("_unmarshal_big_Int", false, nil, "R", &ast.SelectorExpr{
+		X:   ast.NewIdent("big"),
+		Sel: ast.NewIdent("Int"),
+	}, append(d.statements, returnNil()))
+}
+
+func (c *constructor) marshalBinaryHelper(name, method, iface string) *ast.FuncDecl {
+	c.use("encoding")
+
+	d := c.subConstructor()
+
+	d.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("b"),
+			ast.NewIdent("err"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			marshalCall(method),
+		},
+	})
+	d.addStatement(returnErr())
+	d.writeBytes(ast.NewIdent("b"))
+
+	fn := helperFunc(name, "W", "StickyWriter", "w", &ast.SelectorExpr{
+		X:   ast.NewIdent("encoding"),
+		Sel: ast.NewIdent(iface),
+	}, append(d.statements, returnNil()))
+	fn.Type.Params.List = c.refsParam(fn.Type.Params.List)
+
+	return fn
+}
+
+func marshalCall(method string) *ast.CallExpr {
+	call := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("t"),
+			Sel: ast.NewIdent(method),
+		},
+	}
+
+	if method == "AppendBinary" {
+		call.Args = []ast.Expr{
+			ast.NewIdent("nil"),
+		}
+	}
+
+	return call
+}
+
+func (c *constructor) unmarshalBinaryHelper() *ast.FuncDecl {
+	c.use("encoding")
+
+	d := c.subConstructor()
+
+	d.readBytes(ast.NewIdent("b"))
+
+	fn := helperFunc("_unmarshal_binary", "R", "StickyReader", "r", &ast.SelectorExpr{
+		X:   ast.NewIdent("encoding"),
+		Sel: ast.NewIdent("BinaryUnmarshaler"),
+	}, append(d.statements, &ast.ReturnStmt{
+		Results: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("t"),
+					Sel: ast.NewIdent("UnmarshalBinary"),
+				},
+				Args: []ast.Expr{
+					ast.NewIdent("b"),
+				},
+			},
+		},
+	}))
+	fn.Type.Params.List = c.refsParam(c.limitParam(fn.Type.Params.List))
+
+	return fn
+}
+
+func (c *constructor) writeBytes(name ast.Expr) {
+	c.addWriter("WriteUintX", &ast.CallExpr{
+		Fun: ast.NewIdent("uint64"),
+		Args: []ast.Expr{
+			&ast.CallExpr{
+				Fun:  ast.NewIdent("len"),
+				Args: []ast.Expr{name},
+			},
+		},
+	})
+	c.addWriter("Write", name)
+}
+
+func (c *constructor) readBytes(name *ast.Ident) {
+	c.use("io")
+
+	// The payload is a byte encoding, not a slice of elements, so it is
+	// only accounted against the total allocation limit.
+	length, _ := c.checkLength("payload length", 0, 1, readCall("ReadUintX"), types.Uint64)
+
+	c.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{name},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: ast.NewIdent("make"),
+				Args: []ast.Expr{
+					&ast.ArrayType{
+						Elt: ast.NewIdent("byte"),
+					},
+					length,
+				},
+			},
+		},
+	})
+
+	if c.limits.enabled() {
+		c.addStatement(&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.SelectorExpr{
+					X:   ast.NewIdent("l"),
+					Sel: ast.NewIdent("err"),
+				},
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					c.returnLimits(),
+				},
+			},
+		})
+	}
+
+	c.addStatement(readFull(name, ast.NewIdent("err")))
+}
+
+func readFull(name, result ast.Expr) ast.Stmt {
+	return &ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("_"),
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent("io"),
+						Sel: ast.NewIdent("ReadFull"),
+					},
+					Args: []ast.Expr{
+						ast.NewIdent("r"),
+						name,
+					},
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						result,
+					},
+				},
+			},
+		},
+	}
+}
+
+func helperFunc(name, typeParam, constraint, stream string, iface ast.Expr, body []ast.Stmt) *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(name),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent(typeParam),
+						},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent(constraint),
+						},
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("T"),
+						},
+						Type: iface,
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("t"),
+						},
+						Type: ast.NewIdent("T"),
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent(stream),
+						},
+						Type: ast.NewIdent(typeParam),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: body,
+		},
+	}
+}
+
+func readCall(method string) *ast.CallExpr {
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("r"),
+			Sel: ast.NewIdent(method),
+		},
+	}
+}
+
+func returnNil() *ast.ReturnStmt {
+	return &ast.ReturnStmt{
+		Results: []ast.Expr{
+			ast.NewIdent("nil"),
+		},
+	}
+}
+
+func returnErr() *ast.IfStmt {
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("err"),
+					},
+				},
+			},
+		},
+	}
+}
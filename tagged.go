@@ -0,0 +1,633 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+func (c *constructor) taggedStruct(typ types.Type) (*types.Struct, bool) {
+	if !c.tagged {
+		return nil, false
+	}
+
+	t, ok := typ.Underlying().(*types.Struct)
+
+	return t, ok
+}
+
+func (c *constructor) eachField(t *types.Struct, marshal bool, fn func(structField, ast.Expr)) {
+	fields, err := c.structFields(t)
+	if err != nil {
+		c.setError(err)
+
+		return
+	}
+
+	conf := c.config
+	path := c.path
+	errPath := c.errPath
+
+	for _, field := range fields {
+		if c.config, err = conf.field(field); err != nil {
+			c.setError(err)
+		} else if c.skip && !c.supported(field.Type(), nil) {
+			continue
+		}
+
+		c.path = path + "." + field.Name()
+		c.errPath = errPath
+
+		c.pushPath(pathPart{name: "." + field.Name()})
+
+		if c.versioned(field) && field.removed > 0 && marshal {
+			continue
+		}
+
+		fn(field, &ast.SelectorExpr{
+			X:   ast.NewIdent("t"),
+			Sel: ast.NewIdent(field.Name()),
+		})
+	}
+
+	c.config = conf
+	c.path = path
+	c.errPath = errPath
+}
+
+func (c *constructor) writeTagged(t *types.Struct) {
+	if !c.sizing {
+		c.helpers["_write_field"] = true
+	}
+
+	c.eachField(t, true, func(field structField, name ast.Expr) {
+		d := c.subConstructor()
+
+		d.writeType(name, field.Type())
+
+		var write ast.Stmt = &ast.IfStmt{
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("err"),
+				},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: ast.NewIdent("_write_field"),
+						Args: []ast.Expr{
+							ast.NewIdent("w"),
+							&ast.BasicLit{
+								Kind:  token.INT,
+								Value: strconv.Itoa(field.number),
+							},
+							&ast.FuncLit{
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{
+										List: []*ast.Field{
+											{
+												Names: []*ast.Ident{
+													ast.NewIdent("w"),
+												},
+												Type: &ast.StarExpr{
+													X: &ast.SelectorExpr{
+														X:   ast.NewIdent("byteio"),
+														Sel: ast.NewIdent("Mem" + c.endian()),
+													},
+												},
+											},
+										},
+									},
+									Results: &ast.FieldList{
+										List: []*ast.Field{
+											{
+												Type: ast.NewIdent("error"),
+											},
+										},
+									},
+								},
+								Body: &ast.BlockStmt{
+									List: append(d.statements, returnNil()),
+								},
+							},
+						},
+					},
+				},
+			},
+			Cond: &ast.BinaryExpr{
+				X:  ast.NewIdent("err"),
+				Op: token.NEQ,
+				Y:  ast.NewIdent("nil"),
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							ast.NewIdent("err"),
+						},
+					},
+				},
+			},
+		}
+
+		if c.sizing {
+			write = c.sizeTagged(field.number, d.statements)
+		}
+
+		if c.omitZero && omittable(field.Type()) {
+			c.addStatement(&ast.IfStmt{
+				Cond: c.nonZero(name, field.Type()),
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{write},
+				},
+			})
+		} else {
+			c.addStatement(write)
+		}
+	})
+
+	c.addWriter("WriteUintX", &ast.BasicLit{
+		Kind:  token.INT,
+		Value: "0",
+	})
+}
+
+func (c *constructor) readTagged(t *types.Struct) {
+	c.unchecked = true
+	c.helpers["_read_field"] = true
+	c.helpers["_zero"] = true
+
+	clauses := []ast.Stmt{}
+
+	c.eachField(t, false, func(field structField, name ast.Expr) {
+		c.addStatement(&ast.ExprStmt{
+			X: &ast.CallExpr{
+				Fun: ast.NewIdent("_zero"),
+				Args: []ast.Expr{
+					addr(name),
+				},
+			},
+		})
+
+		d := c.subConstructor()
+
+		d.addStatement(&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("r"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.UnaryExpr{
+					Op: token.AND,
+					X: &ast.CompositeLit{
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent("Sticky" + c.endian() + "Reader"),
+						},
+						Elts: []ast.Expr{
+							&ast.KeyValueExpr{
+								Key:   ast.NewIdent("Reader"),
+								Value: ast.NewIdent("lr"),
+							},
+						},
+					},
+				},
+			},
+		})
+		d.readType(name, field.Type())
+		d.checkReader()
+
+		clauses = append(clauses, &ast.CaseClause{
+			List: []ast.Expr{
+				&ast.BasicLit{
+					Kind:  token.INT,
+					Value: strconv.Itoa(field.number),
+				},
+			},
+			Body: []ast.Stmt{
+				&ast.IfStmt{
+					Init: &ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("err"),
+						},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							readFieldCall(&ast.FuncLit{
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{
+										List: []*ast.Field{
+											{
+												Names: []*ast.Ident{
+													ast.NewIdent("lr"),
+												},
+												Type: &ast.SelectorExpr{
+													X:   ast.NewIdent("io"),
+													Sel: ast.NewIdent("Reader"),
+												},
+											},
+										},
+									},
+									Results: &ast.FieldList{
+										List: []*ast.Field{
+											{
+												Type: ast.NewIdent("error"),
+											},
+										},
+									},
+								},
+								Body: &ast.BlockStmt{
+									List: append(d.statements, returnNil()),
+								},
+							}),
+						},
+					},
+					Cond: &ast.BinaryExpr{
+						X:  ast.NewIdent("err"),
+						Op: token.NEQ,
+						Y:  ast.NewIdent("nil"),
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ReturnStmt{
+								Results: []ast.Expr{
+									ast.NewIdent("err"),
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	})
+
+	c.use("io")
+
+	c.addStatement(&ast.ForStmt{
+		For: c.newLine(),
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("tag"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				readCall("ReadUintX"),
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("tag"),
+			Op: token.NEQ,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "0",
+			},
+		},
+		Post: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("tag"),
+			},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{
+				readCall("ReadUintX"),
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.SwitchStmt{
+					Tag: ast.NewIdent("tag"),
+					Body: &ast.BlockStmt{
+						List: append(clauses, &ast.CaseClause{
+							Body: []ast.Stmt{
+								&ast.ExprStmt{
+									X: readFieldCall(ast.NewIdent("nil")),
+								},
+							},
+						}),
+					},
+				},
+			},
+		},
+	})
+}
+
+func readFieldCall(fn ast.Expr) *ast.CallExpr {
+	return &ast.CallExpr{
+		Fun: ast.NewIdent("_read_field"),
+		Args: []ast.Expr{
+			ast.NewIdent("r"),
+			fn,
+		},
+	}
+}
+
+func writeFieldFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_write_field"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("W")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent("StickyWriter"),
+						},
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("M")},
+						Type: &ast.UnaryExpr{
+							Op: token.TILDE,
+							X: &ast.ArrayType{
+								Elt: ast.NewIdent("byte"),
+							},
+						},
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("w")},
+						Type:  ast.NewIdent("W"),
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("tag")},
+						Type:  ast.NewIdent("uint64"),
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("f")},
+						Type: &ast.FuncType{
+							Params: &ast.FieldList{
+								List: []*ast.Field{
+									{
+										Type: &ast.StarExpr{
+											X: ast.NewIdent("M"),
+										},
+									},
+								},
+							},
+							Results: &ast.FieldList{
+								List: []*ast.Field{
+									{
+										Type: ast.NewIdent("error"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{ast.NewIdent("buf")},
+								Type:  ast.NewIdent("M"),
+							},
+						},
+					},
+				},
+				&ast.IfStmt{
+					Init: &ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("err"),
+						},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: ast.NewIdent("f"),
+								Args: []ast.Expr{
+									&ast.UnaryExpr{
+										Op: token.AND,
+										X:  ast.NewIdent("buf"),
+									},
+								},
+							},
+						},
+					},
+					Cond: &ast.BinaryExpr{
+						X:  ast.NewIdent("err"),
+						Op: token.NEQ,
+						Y:  ast.NewIdent("nil"),
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.ReturnStmt{
+								Results: []ast.Expr{
+									ast.NewIdent("err"),
+								},
+							},
+						},
+					},
+				},
+				&ast.ExprStmt{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("w"),
+							Sel: ast.NewIdent("WriteUintX"),
+						},
+						Args: []ast.Expr{
+							ast.NewIdent("tag"),
+						},
+					},
+				},
+				&ast.ExprStmt{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("w"),
+							Sel: ast.NewIdent("WriteUintX"),
+						},
+						Args: []ast.Expr{
+							&ast.CallExpr{
+								Fun: ast.NewIdent("uint64"),
+								Args: []ast.Expr{
+									&ast.CallExpr{
+										Fun: ast.NewIdent("len"),
+										Args: []ast.Expr{
+											ast.NewIdent("buf"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.ExprStmt{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("w"),
+							Sel: ast.NewIdent("Write"),
+						},
+						Args: []ast.Expr{
+							ast.NewIdent("buf"),
+						},
+					},
+				},
+				returnNil(),
+			},
+		},
+	}
+}
+
+func readFieldFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_read_field"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("R")},
+						Type: &ast.SelectorExpr{
+							X:   ast.NewIdent("byteio"),
+							Sel: ast.NewIdent("StickyReader"),
+						},
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("r")},
+						Type:  ast.NewIdent("R"),
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("f")},
+						Type: &ast.FuncType{
+							Params: &ast.FieldList{
+								List: []*ast.Field{
+									{
+										Type: &ast.SelectorExpr{
+											X:   ast.NewIdent("io"),
+											Sel: ast.NewIdent("Reader"),
+										},
+									},
+								},
+							},
+							Results: &ast.FieldList{
+								List: []*ast.Field{
+									{
+										Type: ast.NewIdent("error"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("lr"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CompositeLit{
+							Type: &ast.SelectorExpr{
+								X:   ast.NewIdent("io"),
+								Sel: ast.NewIdent("LimitedReader"),
+							},
+							Elts: []ast.Expr{
+								&ast.KeyValueExpr{
+									Key:   ast.NewIdent("R"),
+									Value: ast.NewIdent("r"),
+								},
+								&ast.KeyValueExpr{
+									Key: ast.NewIdent("N"),
+									Value: &ast.CallExpr{
+										Fun: ast.NewIdent("int64"),
+										Args: []ast.Expr{
+											readCall("ReadUintX"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{
+									ast.NewIdent("err"),
+								},
+								Type: ast.NewIdent("error"),
+							},
+						},
+					},
+				},
+				&ast.IfStmt{
+					Cond: &ast.BinaryExpr{
+						X:  ast.NewIdent("f"),
+						Op: token.NEQ,
+						Y:  ast.NewIdent("nil"),
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							&ast.AssignStmt{
+								Lhs: []ast.Expr{
+									ast.NewIdent("err"),
+								},
+								Tok: token.ASSIGN,
+								Rhs: []ast.Expr{
+									&ast.CallExpr{
+										Fun: ast.NewIdent("f"),
+										Args: []ast.Expr{
+											&ast.UnaryExpr{
+												Op: token.AND,
+												X:  ast.NewIdent("lr"),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.ExprStmt{
+					X: &ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("io"),
+							Sel: ast.NewIdent("Copy"),
+						},
+						Args: []ast.Expr{
+							&ast.SelectorExpr{
+								X:   ast.NewIdent("io"),
+								Sel: ast.NewIdent("Discard"),
+							},
+							&ast.UnaryExpr{
+								Op: token.AND,
+								X:  ast.NewIdent("lr"),
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("err"),
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const tagName = "marshal"
+
+type structField struct {
+	*types.Var
+	number         int
+	options        []string
+	since, removed uint64
+}
+
+func (c *constructor) structFields(t *types.Struct) ([]structField, error) {
+	var (
+		fields  []structField
+		numbers = make(map[int]string)
+		next    = 1
+	)
+
+	for _, f := range c.visibleFields(t, t) {
+		field := f.Var
+
+		tag, ok := reflect.StructTag(f.tag).Lookup(tagName)
+
+		number := next
+
+		var options []string
+
+		if ok {
+			num, opts, _ := strings.Cut(tag, ",")
+
+			if num != "" {
+				var err error
+
+				if number, err = strconv.Atoi(num); err != nil || number < 1 {
+					return nil, fmt.Errorf("%w: %s: invalid field number %q", ErrInvalidTag, field.Name(), num)
+				}
+			}
+
+			if opts != "" {
+				options = strings.Split(opts, ",")
+			}
+		}
+
+		since, removed, options, err := fieldVersions(field.Name(), options)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := numbers[number]; ok {
+			return nil, fmt.Errorf("%w: %s: field number %d already used by %s", ErrInvalidTag, field.Name(), number, other)
+		}
+
+		numbers[number] = field.Name()
+		next = max(next, number+1)
+		fields = append(fields, structField{
+			Var:     field,
+			number:  number,
+			options: options,
+			since:   since,
+			removed: removed,
+		})
+	}
+
+	slices.SortStableFunc(fields, func(a, b structField) int {
+		return a.number - b.number
+	})
+
+	return fields, nil
+}
+
+type taggedVar struct {
+	*types.Var
+	tag string
+}
+
+func (c *constructor) visibleFields(root, t *types.Struct) []taggedVar {
+	var fields []taggedVar
+
+	for n := range t.NumFields() {
+		field := t.Field(n)
+
+		if field.Name() == "_" || reflect.StructTag(t.Tag(n)).Get(tagName) == "-" {
+			continue
+		} else if c.included(field) {
+			if root == t || promoted(root, field) {
+				fields = append(fields, taggedVar{Var: field, tag: t.Tag(n)})
+			}
+		} else if s, ok := embeddedStruct(field); ok {
+			fields = append(fields, c.visibleFields(root, s)...)
+		}
+	}
+
+	return fields
+}
+
+func (c *constructor) included(field *types.Var) bool {
+	if field.Exported() || c.unexported && field.Pkg() == c.pkg {
+		return true
+	} else if !field.Embedded() || field.Pkg() != c.pkg {
+		return false
+	}
+
+	switch t := field.Type().(type) {
+	case *types.Pointer:
+		return true
+	case *types.Named:
+		return stdlibFunc(t) != "" || binaryFunc(t, false) != ""
+	}
+
+	return false
+}
+
+func embeddedStruct(field *types.Var) (*types.Struct, bool) {
+	named, ok := field.Type().(*types.Named)
+	if !ok || !field.Embedded() || stdlibFunc(named) != "" || binaryFunc(named, false) != "" {
+		return nil, false
+	}
+
+	s, ok := named.Underlying().(*types.Struct)
+
+	return s, ok
+}
+
+func promoted(root *types.Struct, field *types.Var) bool {
+	obj, _, _ := types.LookupFieldOrMethod(root, false, field.Pkg(), field.Name())
+	v, ok := obj.(*types.Var)
+
+	return ok && v.Origin() == field.Origin()
+}
+
+func fieldVersions(name string, opts []string) (uint64, uint64, []string, error) {
+	var (
+		since, removed uint64
+		options        []string
+	)
+
+	for _, opt := range opts {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok || key != "since" && key != "removed" {
+			options = append(options, opt)
+
+			continue
+		}
+
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || v == 0 {
+			return 0, 0, nil, fmt.Errorf("%w: %s: invalid version %q", ErrInvalidTag, name, value)
+		}
+
+		if key == "since" {
+			since = v
+		} else {
+			removed = v
+		}
+	}
+
+	if removed != 0 && since >= removed {
+		return 0, 0, nil, fmt.Errorf("%w: %s: removed in version %d before it was added in version %d", ErrInvalidTag, name, removed, since)
+	}
+
+	return since, removed, options, nil
+}
+
+func (c config) field(f structField) (config, error) {
+	for _, opt := range f.options {
+		switch opt {
+		case "varint":
+			c.varint = true
+		case "fixed":
+			c.varint = false
+		case "len8":
+			c.lenWidth = 8
+		case "len16":
+			c.lenWidth = 16
+		case "len32":
+			c.lenWidth = 32
+		case "len64":
+			c.lenWidth = 64
+		case "lenx":
+			c.lenWidth = 0
+		case "keepnil":
+			c.keepNil = true
+		default:
+			return c, fmt.Errorf("%w: %s: unknown option %q", ErrInvalidTag, f.Name(), opt)
+		}
+	}
+
+	return c, nil
+}
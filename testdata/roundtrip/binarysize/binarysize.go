@@ -0,0 +1,12 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -varint Message
+
+type Message struct {
+	ID     uint64
+	Delta  int32
+	Text   string
+	Values []float32
+	Attrs  map[string][]byte
+	Reply  *Message
+}
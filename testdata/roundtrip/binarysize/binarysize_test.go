@@ -0,0 +1,19 @@
+package roundtrip
+
+import "testing"
+
+func TestBinarySize(t *testing.T) {
+	for n, in := range [...]Message{
+		{},
+		{ID: 1 << 40, Delta: -1000, Text: "hello"},
+		{Values: []float32{1, 2, 3}, Attrs: map[string][]byte{"a": {1}, "bb": make([]byte, 300)}},
+		{ID: 5, Reply: &Message{Text: "reply", Reply: &Message{Delta: 1 << 30}}},
+	} {
+		data, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if size := in.BinarySize(); size != len(data) {
+			t.Errorf("test %d: expecting size %d, got %d", n+1, len(data), size)
+		}
+	}
+}
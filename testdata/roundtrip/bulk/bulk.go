@@ -0,0 +1,43 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Blob BigBlob Elems BigElems StrictBlob
+
+type Blob struct {
+	Raw   []byte
+	Fixed [4]byte
+	Vals  []uint32
+	Temps [3]float64
+}
+
+//marshal:bigendian
+type BigBlob struct {
+	Vals  []uint16
+	Pairs [2]int32
+}
+
+type (
+	Byte   struct{ V uint8 }
+	Word16 struct{ V uint16 }
+	Word32 struct{ V uint32 }
+	Int32  struct{ V int32 }
+	Float  struct{ V float64 }
+)
+
+type Elems struct {
+	Raw   []Byte
+	Fixed [4]Byte
+	Vals  []Word32
+	Temps [3]Float
+}
+
+//marshal:bigendian
+type BigElems struct {
+	Vals  []Word16
+	Pairs [2]Int32
+}
+
+//marshal:strict
+type StrictBlob struct {
+	Raw  []byte
+	Vals []uint32
+}
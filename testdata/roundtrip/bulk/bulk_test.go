@@ -0,0 +1,76 @@
+package roundtrip
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBulk(t *testing.T) {
+	in := Blob{
+		Raw:   []byte("payload"),
+		Fixed: [4]byte{1, 2, 3, 4},
+		Vals:  []uint32{5, 6, 7},
+		Temps: [3]float64{8.5, 9.25, 10.125},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Blob
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestBulkByteOrder(t *testing.T) {
+	in := BigBlob{Vals: []uint16{0x0102, 0x0304}, Pairs: [2]int32{0x05060708, -1}}
+	expected := []byte{2, 1, 2, 3, 4, 5, 6, 7, 8, 0xff, 0xff, 0xff, 0xff}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(data, expected) {
+		t.Fatalf("expecting %v, got %v", expected, data)
+	}
+
+	var got BigBlob
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestBulkMatchesElems(t *testing.T) {
+	for n, test := range [...]struct {
+		bulk, elems interface{ MarshalBinary() ([]byte, error) }
+	}{
+		{
+			&Blob{Raw: []byte("ab"), Fixed: [4]byte{1, 2, 3, 4}, Vals: []uint32{5, 0x01020304}, Temps: [3]float64{8.5, -1, 0}},
+			&Elems{Raw: []Byte{{'a'}, {'b'}}, Fixed: [4]Byte{{1}, {2}, {3}, {4}}, Vals: []Word32{{5}, {0x01020304}}, Temps: [3]Float{{8.5}, {-1}, {0}}},
+		},
+		{
+			&BigBlob{Vals: []uint16{0x0102, 0x0304}, Pairs: [2]int32{0x05060708, -1}},
+			&BigElems{Vals: []Word16{{0x0102}, {0x0304}}, Pairs: [2]Int32{{0x05060708}, {-1}}},
+		},
+	} {
+		bulk, err := test.bulk.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		elems, err := test.elems.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !bytes.Equal(bulk, elems) {
+			t.Errorf("test %d: expecting bulk encoding %v to match element encoding %v", n+1, bulk, elems)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package roundtrip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBlobShortReads(t *testing.T) {
+	in := Blob{
+		Raw:   []byte("payload"),
+		Fixed: [4]byte{1, 2, 3, 4},
+		Vals:  []uint32{5, 6, 7},
+		Temps: [3]float64{8.5, 9.25, 10.125},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Blob
+
+	if _, err := got.ReadFrom(iotest.OneByteReader(bytes.NewReader(data))); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+
+	for n := range len(data) {
+		var got Blob
+
+		if _, err := got.ReadFrom(bytes.NewReader(data[:n])); err == nil {
+			t.Errorf("test %d: expecting error reading truncated data", n+1)
+		}
+	}
+}
+
+func TestBulkShortMatchesElems(t *testing.T) {
+	data, err := (&Blob{
+		Raw:   []byte("payload"),
+		Fixed: [4]byte{1, 2, 3, 4},
+		Vals:  []uint32{5, 6, 7},
+		Temps: [3]float64{8.5, 9.25, 10.125},
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for n := range len(data) {
+		var (
+			blob  Blob
+			elems Elems
+		)
+
+		if err := blob.UnmarshalBinary(data[:n]); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if err := elems.UnmarshalBinary(data[:n]); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if conv := blobElems(blob); !reflect.DeepEqual(conv, elems) {
+			t.Errorf("test %d: expecting bulk decode %#v to match element decode %#v", n+1, conv, elems)
+		}
+	}
+}
+
+func TestBigBulkShortMatchesElems(t *testing.T) {
+	data, err := (&BigBlob{Vals: []uint16{0x0102, 0x0304}, Pairs: [2]int32{0x05060708, -1}}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for n := range len(data) {
+		var (
+			blob  BigBlob
+			elems BigElems
+		)
+
+		if err := blob.UnmarshalBinary(data[:n]); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if err := elems.UnmarshalBinary(data[:n]); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if conv := bigBlobElems(blob); !reflect.DeepEqual(conv, elems) {
+			t.Errorf("test %d: expecting bulk decode %#v to match element decode %#v", n+1, conv, elems)
+		}
+	}
+}
+
+func TestStrictBlobShortReads(t *testing.T) {
+	data, err := (&StrictBlob{Raw: []byte("raw"), Vals: []uint32{1, 2}}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for n := range len(data) {
+		var got StrictBlob
+
+		if err := got.UnmarshalBinary(data[:n]); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("test %d: expecting io.ErrUnexpectedEOF, got %v", n+1, err)
+		}
+	}
+}
+
+func blobElems(b Blob) Elems {
+	var e Elems
+
+	if b.Raw != nil {
+		e.Raw = make([]Byte, len(b.Raw))
+	}
+
+	for n, v := range b.Raw {
+		e.Raw[n].V = v
+	}
+
+	for n, v := range b.Fixed {
+		e.Fixed[n].V = v
+	}
+
+	if b.Vals != nil {
+		e.Vals = make([]Word32, len(b.Vals))
+	}
+
+	for n, v := range b.Vals {
+		e.Vals[n].V = v
+	}
+
+	for n, v := range b.Temps {
+		e.Temps[n].V = v
+	}
+
+	return e
+}
+
+func bigBlobElems(b BigBlob) BigElems {
+	var e BigElems
+
+	if b.Vals != nil {
+		e.Vals = make([]Word16, len(b.Vals))
+	}
+
+	for n, v := range b.Vals {
+		e.Vals[n].V = v
+	}
+
+	for n, v := range b.Pairs {
+		e.Pairs[n].V = v
+	}
+
+	return e
+}
@@ -0,0 +1,15 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -bigendian Header Little
+
+type Header struct {
+	Magic   uint32
+	Version uint16
+	Flags   int8
+}
+
+//marshal:littleendian
+type Little struct {
+	Magic   uint32
+	Version uint16
+}
@@ -0,0 +1,55 @@
+package roundtrip
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestByteOrder(t *testing.T) {
+	header := Header{Magic: 0x01020304, Version: 0x0506, Flags: -1}
+
+	data, err := header.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := []byte{1, 2, 3, 4, 5, 6, 0xff}; !bytes.Equal(data, expect) {
+		t.Errorf("expecting big-endian bytes %v, got %v", expect, data)
+	}
+
+	var got Header
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, header) {
+		t.Errorf("expecting %#v, got %#v", header, got)
+	}
+
+	little := Little{Magic: 0x01020304, Version: 0x0506}
+
+	data, err = little.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := []byte{4, 3, 2, 1, 6, 5}; !bytes.Equal(data, expect) {
+		t.Errorf("expecting little-endian bytes %v, got %v", expect, data)
+	}
+}
+
+func TestByteOrderStream(t *testing.T) {
+	var buf bytes.Buffer
+
+	header := Header{Magic: 7, Version: 8, Flags: 9}
+
+	if _, err := header.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := []byte{0, 0, 0, 7, 0, 8, 9}; !bytes.Equal(buf.Bytes(), expect) {
+		t.Errorf("expecting big-endian bytes %v, got %v", expect, buf.Bytes())
+	}
+
+	var got Header
+
+	if _, err := got.ReadFrom(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != header {
+		t.Errorf("expecting %#v, got %#v", header, got)
+	}
+}
@@ -0,0 +1,20 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Order Shipment
+
+type Order struct {
+	ID    uint32
+	Items []Item
+	Meta  map[string]Item
+}
+
+type Item struct {
+	Name  string
+	Price uint64
+}
+
+//marshal:strict
+type Shipment struct {
+	Ref    uint8
+	Orders []Order
+}
@@ -0,0 +1,87 @@
+package roundtrip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDecodeErrorPath(t *testing.T) {
+	in := Order{ID: 1, Items: []Item{{Name: "a", Price: 1}, {Name: "b", Price: 2}}}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for n, test := range [...]struct {
+		length int
+		path   string
+	}{
+		{2, ""},
+		{4, ""},
+		{6, "Items[0]"},
+		{10, "Items[0]"},
+		{16, "Items[1]"},
+		{20, "Items[1]"},
+		{25, ""},
+	} {
+		var (
+			got Order
+			de  *DecodeError
+		)
+
+		if _, err := got.ReadFrom(bytes.NewReader(data[:test.length])); !errors.As(err, &de) {
+			t.Errorf("test %d: expecting DecodeError, got %v", n+1, err)
+		} else if de.Path != test.path {
+			t.Errorf("test %d: expecting path %q, got %q", n+1, test.path, de.Path)
+		} else if de.Offset != int64(test.length) {
+			t.Errorf("test %d: expecting offset %d, got %d", n+1, test.length, de.Offset)
+		} else if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("test %d: expecting unexpected EOF, got %v", n+1, de.Err)
+		}
+	}
+}
+
+func TestDecodeErrorNested(t *testing.T) {
+	in := Shipment{
+		Ref: 1,
+		Orders: []Order{
+			{ID: 2},
+			{ID: 3, Items: []Item{{Name: "a", Price: 4}}},
+		},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for n, test := range [...]struct {
+		length int
+		path   string
+	}{
+		{1, ""},
+		{4, "Orders[0]"},
+		{10, "Orders[1]"},
+		{13, "Orders[1].Items[0]"},
+		{20, "Orders[1].Items[0]"},
+		{23, "Orders[1]"},
+	} {
+		var (
+			got Shipment
+			de  *DecodeError
+		)
+
+		if err := got.UnmarshalBinary(data[:test.length]); !errors.As(err, &de) {
+			t.Errorf("test %d: expecting DecodeError, got %v", n+1, err)
+		} else if de.Path != test.path {
+			t.Errorf("test %d: expecting path %q, got %q", n+1, test.path, de.Path)
+		} else if de.Offset != int64(test.length) {
+			t.Errorf("test %d: expecting offset %d, got %d", n+1, test.length, de.Offset)
+		} else if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("test %d: expecting unexpected EOF, got %v", n+1, de.Err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package roundtrip
+
+import (
+	"errors"
+	"net/url"
+)
+
+//go:generate marshal -o marshal.go Envelope
+
+type Opaque struct {
+	secret string
+}
+
+func (o *Opaque) MarshalBinary() ([]byte, error) {
+	return []byte(o.secret), nil
+}
+
+func (o *Opaque) UnmarshalBinary(b []byte) error {
+	o.secret = string(b)
+
+	return nil
+}
+
+type Appended struct {
+	n uint8
+}
+
+func (a Appended) AppendBinary(b []byte) ([]byte, error) {
+	return append(b, a.n, ^a.n), nil
+}
+
+func (a *Appended) UnmarshalBinary(b []byte) error {
+	if len(b) != 2 || b[0] != ^b[1] {
+		return errors.New("bad appended value")
+	}
+
+	a.n = b[0]
+
+	return nil
+}
+
+type Envelope struct {
+	Opaque   Opaque
+	Appended Appended
+	Link     *url.URL
+}
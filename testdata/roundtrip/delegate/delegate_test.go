@@ -0,0 +1,34 @@
+package roundtrip
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestDelegate(t *testing.T) {
+	link, _ := url.Parse("https://example.com/path?q=1")
+	in := Envelope{Opaque: Opaque{secret: "abc"}, Appended: Appended{n: 7}, Link: link}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if prefix := []byte{3, 'a', 'b', 'c', 2, 7, ^uint8(7)}; !bytes.HasPrefix(data, prefix) {
+		t.Errorf("expecting bytes to start with %v, got %v", prefix, data)
+	}
+
+	var got Envelope
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+
+	data[5] = 0
+
+	if err := got.UnmarshalBinary(data); err == nil {
+		t.Error("expecting error from delegated UnmarshalBinary")
+	}
+}
@@ -0,0 +1,39 @@
+package roundtrip
+
+import "errors"
+
+//go:generate marshal -o marshal.go Item Wrapped
+
+type base struct {
+	ID   uint32
+	Name string
+}
+
+type Item struct {
+	base
+	Name  string
+	Extra uint8
+}
+
+type Stamp struct {
+	Value uint8
+}
+
+func (s Stamp) MarshalBinary() ([]byte, error) {
+	return []byte{^s.Value}, nil
+}
+
+func (s *Stamp) UnmarshalBinary(data []byte) error {
+	if len(data) != 1 {
+		return errors.New("invalid stamp")
+	}
+
+	s.Value = ^data[0]
+
+	return nil
+}
+
+type Wrapped struct {
+	Stamp
+	Count uint8
+}
@@ -0,0 +1,43 @@
+package roundtrip
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEmbeddedPromoted(t *testing.T) {
+	in := Item{base: base{ID: 1, Name: "shadowed"}, Name: "b", Extra: 2}
+	expected := Item{base: base{ID: 1}, Name: "b", Extra: 2}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Item
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != expected {
+		t.Errorf("expecting %#v, got %#v", expected, got)
+	}
+}
+
+func TestEmbeddedBinary(t *testing.T) {
+	in := Wrapped{Stamp: Stamp{Value: 3}, Count: 4}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expected := []byte{1, ^byte(3), 4}; !bytes.Equal(data, expected) {
+		t.Fatalf("expecting %v, got %v", expected, data)
+	}
+
+	var got Wrapped
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != in {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
@@ -0,0 +1,28 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Record BigRecord StrictRecord
+
+type Vec3 struct {
+	X, Y, Z float32
+}
+
+type Record struct {
+	ID    uint32
+	Pos   [2]Vec3
+	Flags [3]bool
+	Kind  int8
+	Time  int64
+}
+
+//marshal:bigendian
+type BigRecord struct {
+	ID   uint16
+	Vals [2]float64
+	On   bool
+}
+
+//marshal:strict
+type StrictRecord struct {
+	ID  uint16
+	Pos Vec3
+}
@@ -0,0 +1,122 @@
+package roundtrip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFixedSize(t *testing.T) {
+	if RecordBinarySize != 4+2*3*4+3+1+8 {
+		t.Errorf("unexpected RecordBinarySize %d", RecordBinarySize)
+	} else if BigRecordBinarySize != 2+2*8+1 {
+		t.Errorf("unexpected BigRecordBinarySize %d", BigRecordBinarySize)
+	}
+
+	var r Record
+
+	if size := r.BinarySize(); size != RecordBinarySize {
+		t.Errorf("expecting BinarySize %d, got %d", RecordBinarySize, size)
+	}
+}
+
+func TestFixedMatchesStream(t *testing.T) {
+	in := Record{ID: 0x01020304, Pos: [2]Vec3{{1, 2, 3}, {-4, 5.5, 6}}, Flags: [3]bool{true, false, true}, Kind: -3, Time: -1}
+
+	var stream bytes.Buffer
+
+	if _, err := in.WriteTo(&stream); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := in.AppendBinary([]byte{0xaa})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(data[1:], stream.Bytes()) || data[0] != 0xaa {
+		t.Errorf("expecting fixed encoding %v to match stream encoding %v", data[1:], stream.Bytes())
+	}
+
+	var got Record
+
+	if err := got.UnmarshalBinary(data[1:]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != in {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+
+	want := in
+	want.Time = 0
+
+	if err := got.UnmarshalBinary(data[1 : RecordBinarySize-7]); err != nil {
+		t.Errorf("unexpected error with short input: %s", err)
+	} else if got != want {
+		t.Errorf("expecting missing fields to be zero, got %#v", got)
+	}
+}
+
+func TestFixedBigEndian(t *testing.T) {
+	in := BigRecord{ID: 0x0102, Vals: [2]float64{1, -2}, On: true}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(data) != BigRecordBinarySize || data[0] != 1 || data[1] != 2 {
+		t.Errorf("expecting big-endian encoding of size %d, got %v", BigRecordBinarySize, data)
+	}
+
+	var stream bytes.Buffer
+
+	if _, err := in.WriteTo(&stream); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !bytes.Equal(data, stream.Bytes()) {
+		t.Errorf("expecting fixed encoding %v to match stream encoding %v", data, stream.Bytes())
+	}
+
+	var got BigRecord
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != in {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestFixedStrict(t *testing.T) {
+	if StrictRecordBinarySize != 2+3*4 {
+		t.Errorf("unexpected StrictRecordBinarySize %d", StrictRecordBinarySize)
+	}
+
+	in := StrictRecord{ID: 0x0102, Pos: Vec3{1, -2, 3.5}}
+
+	data, err := in.AppendBinary([]byte{0xaa})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(data) != 1+StrictRecordBinarySize || data[0] != 0xaa || data[1] != 2 || data[2] != 1 {
+		t.Fatalf("unexpected encoding %v", data)
+	}
+
+	var got StrictRecord
+
+	if err := got.UnmarshalBinary(data[1:]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != in {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+
+	if err := got.UnmarshalBinary(append(data[1:], 0)); !errors.Is(err, ErrTrailingBytes) {
+		t.Errorf("expecting ErrTrailingBytes for trailing input, got %v", err)
+	}
+
+	for n := range StrictRecordBinarySize {
+		if err := got.UnmarshalBinary(data[1 : 1+n]); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("test %d: expecting io.ErrUnexpectedEOF for short input, got %v", n+1, err)
+		}
+	}
+
+	var record Record
+
+	if err := record.UnmarshalBinary(make([]byte, RecordBinarySize+3)); err != nil {
+		t.Errorf("unexpected error with trailing bytes: %s", err)
+	}
+}
@@ -0,0 +1,31 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Page Pair Set User Holder
+
+import "cmp"
+
+type Page[T any] struct {
+	Items []T
+	Next  *Page[T]
+	Total int
+}
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+//marshal:sortkeys
+type Set[K cmp.Ordered] map[K]struct{}
+
+type User struct {
+	Name string
+	Age  int
+}
+
+type Holder struct {
+	Users Page[User]
+	Pairs []Pair[string, User]
+	Set   Set[int]
+	Names Set[string]
+}
@@ -0,0 +1,39 @@
+package roundtrip
+
+import (
+	"encoding"
+	"reflect"
+	"testing"
+)
+
+func TestGenericsRoundTrip(t *testing.T) {
+	for n, test := range [...]struct {
+		in, out interface {
+			encoding.BinaryMarshaler
+			encoding.BinaryUnmarshaler
+		}
+	}{
+		{
+			&Holder{
+				Users: Page[User]{Items: []User{{"a", 1}, {"b", 2}}, Next: &Page[User]{Items: []User{{"g", 4}}, Total: 3}, Total: 2},
+				Pairs: []Pair[string, User]{{"x", User{"c", 3}}},
+				Set:   Set[int]{1: {}, -2: {}},
+				Names: Set[string]{"d": {}},
+			},
+			new(Holder),
+		},
+		{&Set[int]{3: {}, 4: {}}, new(Set[int])},
+		{&Set[string]{"e": {}}, new(Set[string])},
+		{&Page[User]{Items: []User{{"f", 5}}}, new(Page[User])},
+		{&Pair[int8, float64]{Key: 6, Val: 7.5}, new(Pair[int8, float64])},
+	} {
+		data, err := test.in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if err := test.out.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(test.out, test.in) {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, test.in, test.out)
+		}
+	}
+}
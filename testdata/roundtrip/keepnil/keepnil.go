@@ -0,0 +1,10 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -keepnil Lists
+
+type Lists struct {
+	Ints   []int32
+	Bytes  []byte
+	Nested [][]string
+	Map    map[string][]uint8
+}
@@ -0,0 +1,28 @@
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeepNil(t *testing.T) {
+	for n, in := range [...]Lists{
+		{},
+		{Ints: []int32{}, Bytes: []byte{}, Nested: [][]string{}, Map: map[string][]uint8{}},
+		{Nested: [][]string{nil, {}, {"a"}}, Map: map[string][]uint8{"nil": nil, "empty": {}}},
+		{Ints: []int32{1}, Bytes: []byte{2}},
+	} {
+		data, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		var got Lists
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(got, in) {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, in, got)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -maxslice=4 -maxalloc=64 Bounded
+
+import (
+	"math/big"
+	"net/netip"
+)
+
+type Bounded struct {
+	Addr  netip.Addr
+	Int   *big.Int
+	Items []int8
+}
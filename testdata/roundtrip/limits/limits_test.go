@@ -0,0 +1,57 @@
+package roundtrip
+
+import (
+	"errors"
+	"math/big"
+	"net/netip"
+	"testing"
+)
+
+func TestPayloadsIgnoreSliceLimit(t *testing.T) {
+	in := Bounded{
+		Addr:  netip.MustParseAddr("2001:db8::1"),
+		Int:   big.NewInt(1 << 40),
+		Items: []int8{1, 2, 3, 4},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Bounded
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got.Addr != in.Addr {
+		t.Errorf("expecting addr %s, got %s", in.Addr, got.Addr)
+	} else if got.Int.Cmp(in.Int) != 0 {
+		t.Errorf("expecting int %s, got %s", in.Int, got.Int)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for n, test := range [...]struct {
+		in    Bounded
+		limit string
+	}{
+		{Bounded{Int: new(big.Int), Items: []int8{1, 2, 3, 4, 5}}, "slice length"},
+		{Bounded{Int: new(big.Int).Lsh(big.NewInt(1), 1024)}, "total allocation"},
+	} {
+		data, err := test.in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		var (
+			got Bounded
+			le  *LimitError
+		)
+
+		if err := got.UnmarshalBinary(data); !errors.As(err, &le) {
+			t.Errorf("test %d: expecting limit error, got %v", n+1, err)
+		} else if le.Limit != test.limit {
+			t.Errorf("test %d: expecting limit %q, got %q", n+1, test.limit, le.Limit)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -maxslice=4 -maxmap=2 -maxstring=5 -maxdepth=8 Limited Chain Tree
+
+type Limited struct {
+	Items []uint16
+	Chain *Chain
+	Index map[string]uint8
+	Name  string
+}
+
+type Chain struct {
+	Next *Chain
+}
+
+type Tree struct {
+	Kids map[string]*Tree
+}
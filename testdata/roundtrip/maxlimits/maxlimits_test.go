@@ -0,0 +1,94 @@
+package roundtrip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func chain(n int) *Chain {
+	var c *Chain
+
+	for range n {
+		c = &Chain{Next: c}
+	}
+
+	return c
+}
+
+func TestWithinLimits(t *testing.T) {
+	in := Limited{Items: []uint16{1, 2, 3, 4}, Index: map[string]uint8{"a": 1, "b": 2}, Name: "hello", Chain: chain(3)}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Limited
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestLimitErrors(t *testing.T) {
+	for n, test := range [...]struct {
+		in    Limited
+		limit string
+	}{
+		{Limited{Items: make([]uint16, 5)}, "slice length"},
+		{Limited{Index: map[string]uint8{"a": 1, "b": 2, "c": 3}}, "map size"},
+		{Limited{Name: "toolong"}, "string length"},
+		{Limited{Chain: chain(20)}, "nesting depth"},
+	} {
+		data, err := test.in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		var (
+			got Limited
+			le  *LimitError
+		)
+
+		if err := got.UnmarshalBinary(data); !errors.As(err, &le) {
+			t.Errorf("test %d: expecting limit error, got %v", n+1, err)
+		} else if le.Limit != test.limit {
+			t.Errorf("test %d: expecting limit %q, got %q", n+1, test.limit, le.Limit)
+		}
+	}
+}
+
+func TestHugeLength(t *testing.T) {
+	var got Limited
+
+	if err := got.UnmarshalBinary([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}); err == nil {
+		t.Error("expecting error for a huge slice length")
+	}
+}
+
+func TestFirstLimitError(t *testing.T) {
+	in := &Tree{Kids: map[string]*Tree{"toolong": {}}}
+
+	for range 7 {
+		in = &Tree{Kids: map[string]*Tree{"a": in}}
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var (
+		got Tree
+		le  *LimitError
+	)
+
+	if err := got.UnmarshalBinary(data); !errors.As(err, &le) {
+		t.Errorf("expecting limit error, got %v", err)
+	} else if le.Limit != "string length" {
+		t.Errorf("expecting limit %q, got %q", "string length", le.Limit)
+	}
+}
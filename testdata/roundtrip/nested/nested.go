@@ -0,0 +1,54 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Order OrderV1 Customer CustomerV1 Stats
+
+//marshal:tagged
+type ItemV1 struct {
+	SKU string
+}
+
+//marshal:tagged
+type Item struct {
+	SKU      string
+	Quantity uint16
+}
+
+type OrderV1 struct {
+	Items []ItemV1
+	Total uint32
+}
+
+type Order struct {
+	Items []Item
+	Total uint32
+}
+
+//marshal:version 1
+type AddressV1 struct {
+	Street string
+}
+
+//marshal:version 2
+type Address struct {
+	Street string
+	City   string `marshal:",since=2"`
+}
+
+type CustomerV1 struct {
+	Home AddressV1
+	Name string
+}
+
+type Customer struct {
+	Home Address
+	Name string
+}
+
+//marshal:varint
+type Counter struct {
+	N uint32
+}
+
+type Stats struct {
+	Visits Counter
+}
@@ -0,0 +1,56 @@
+package roundtrip
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNestedTagged(t *testing.T) {
+	in := Order{Items: []Item{{SKU: "a", Quantity: 1}, {SKU: "b", Quantity: 2}}, Total: 3}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Order
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+
+	var old OrderV1
+
+	if err := old.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := (OrderV1{Items: []ItemV1{{SKU: "a"}, {SKU: "b"}}, Total: 3}); !reflect.DeepEqual(old, expect) {
+		t.Errorf("expecting %#v, got %#v", expect, old)
+	}
+}
+
+func TestNestedVersion(t *testing.T) {
+	data, err := (&CustomerV1{Home: AddressV1{Street: "street"}, Name: "name"}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := Customer{Home: Address{City: "stale"}}
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := (Customer{Home: Address{Street: "street"}, Name: "name"}); got != expect {
+		t.Errorf("expecting %#v, got %#v", expect, got)
+	}
+}
+
+func TestNestedDirective(t *testing.T) {
+	data, err := (&Stats{Visits: Counter{N: 5}}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := []byte{5}; !bytes.Equal(data, expect) {
+		t.Errorf("expecting %v, got %v", expect, data)
+	}
+}
@@ -0,0 +1,24 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -omitzero Doc DocV1 DocV2
+
+//marshal:version 1
+type DocV1 struct {
+	Title  string
+	Legacy int32
+}
+
+//marshal:version 2
+type DocV2 struct {
+	Title  string
+	Body   string `marshal:",since=2"`
+	Legacy int32
+}
+
+//marshal:version 3
+type Doc struct {
+	Title  string
+	Body   string   `marshal:",since=2"`
+	Legacy int32    `marshal:",removed=3"`
+	Tags   []string `marshal:",since=3"`
+}
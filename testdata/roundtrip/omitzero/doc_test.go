@@ -0,0 +1,56 @@
+package roundtrip
+
+import (
+	"encoding"
+	"reflect"
+	"testing"
+)
+
+func TestDocRoundTrip(t *testing.T) {
+	for n, doc := range [...]Doc{
+		{},
+		{Title: "title"},
+		{Tags: []string{"a", "b"}},
+		{Body: "body", Tags: []string{"c"}},
+		{Title: "title", Body: "body", Tags: []string{"d"}},
+	} {
+		data, err := doc.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		got := Doc{Title: "stale", Body: "stale", Legacy: 1, Tags: []string{"stale"}}
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(got, doc) {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, doc, got)
+		}
+	}
+}
+
+func TestDocOlderVersions(t *testing.T) {
+	for n, test := range [...]struct {
+		old    encoding.BinaryMarshaler
+		expect Doc
+	}{
+		{&DocV1{Title: "title", Legacy: 3}, Doc{Title: "title", Legacy: 3}},
+		{&DocV1{Legacy: 4}, Doc{Legacy: 4}},
+		{&DocV2{Title: "title", Body: "body", Legacy: 5}, Doc{Title: "title", Body: "body", Legacy: 5}},
+		{&DocV2{Body: "body"}, Doc{Body: "body"}},
+		{&DocV2{Legacy: 6}, Doc{Legacy: 6}},
+	} {
+		data, err := test.old.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		var got Doc
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, test.expect, got)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -omitzero Profile Reading
+
+type Profile struct {
+	Name    string
+	Age     uint8
+	Email   string
+	Phone   string
+	Tags    []string
+	Score   float64
+	Active  bool
+	Manager *Profile
+}
+
+type Celsius float32
+
+type Point struct {
+	X, Y float64
+}
+
+type Contact struct {
+	Email string
+	Phone string
+}
+
+type Reading struct {
+	Owner    Contact
+	Temp     Celsius
+	Offset   float64
+	Phase    complex128
+	Position Point
+}
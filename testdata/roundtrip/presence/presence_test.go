@@ -0,0 +1,86 @@
+package roundtrip
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPresence(t *testing.T) {
+	for n, in := range [...]Profile{
+		{},
+		{Name: "name"},
+		{Age: 3, Active: true},
+		{Email: "e", Phone: "p", Tags: []string{"t"}, Score: 1.5, Manager: &Profile{Name: "boss"}},
+	} {
+		data, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		got := Profile{Name: "stale", Age: 1, Email: "stale", Tags: []string{"stale"}, Score: 2, Active: true, Manager: &Profile{}}
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(got, in) {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, in, got)
+		}
+	}
+}
+
+func TestPresenceSize(t *testing.T) {
+	data, err := (&Profile{Name: "n"}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(data) != 3 {
+		t.Errorf("expecting a bitmap and one field in 3 bytes, got %v", data)
+	}
+}
+
+func TestPresenceNested(t *testing.T) {
+	in := Reading{Owner: Contact{Phone: "p"}}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := []byte{1, 2, 1, 'p', 0}; !reflect.DeepEqual(data, expect) {
+		t.Errorf("expecting nested presence bitmap %v, got %v", expect, data)
+	}
+
+	got := Reading{Owner: Contact{Email: "stale"}}
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestPresenceNegativeZero(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	in := Reading{
+		Temp:     Celsius(math.Copysign(0, -1)),
+		Offset:   negZero,
+		Phase:    complex(0, negZero),
+		Position: Point{X: negZero},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Reading
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !math.Signbit(float64(got.Temp)) {
+		t.Error("expecting Temp to keep its sign")
+	} else if !math.Signbit(got.Offset) {
+		t.Error("expecting Offset to keep its sign")
+	} else if !math.Signbit(imag(got.Phase)) {
+		t.Error("expecting Phase to keep its sign")
+	} else if !math.Signbit(got.Position.X) {
+		t.Error("expecting Position to keep its sign")
+	}
+}
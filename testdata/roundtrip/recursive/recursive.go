@@ -0,0 +1,18 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go List Tree
+
+type List struct {
+	Value int16
+	Next  *List
+}
+
+type Tree struct {
+	Name     string
+	Children []Tree
+	Parent   *Leaf
+}
+
+type Leaf struct {
+	Tree *Tree
+}
@@ -0,0 +1,43 @@
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecursive(t *testing.T) {
+	list := List{Value: 1, Next: &List{Value: 2, Next: &List{Value: 3}}}
+
+	data, err := list.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var gotList List
+
+	if err := gotList.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(gotList, list) {
+		t.Errorf("expecting %#v, got %#v", list, gotList)
+	}
+
+	tree := Tree{
+		Name: "root",
+		Children: []Tree{
+			{Name: "a", Children: []Tree{}, Parent: &Leaf{Tree: &Tree{Name: "detached", Children: []Tree{}}}},
+			{Name: "b", Children: []Tree{{Name: "c", Children: []Tree{}}}},
+		},
+	}
+
+	if data, err = tree.MarshalBinary(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var gotTree Tree
+
+	if err := gotTree.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(gotTree, tree) {
+		t.Errorf("expecting %#v, got %#v", tree, gotTree)
+	}
+}
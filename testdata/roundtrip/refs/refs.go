@@ -0,0 +1,25 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -refs Graph List Loop
+
+type Node struct {
+	Name  string
+	Edges []*Node
+}
+
+type Graph struct {
+	Nodes []*Node
+	Root  *Node
+	Count *int
+	Also  *int
+}
+
+type List struct {
+	Value      uint8
+	Prev, Next *List
+}
+
+type Loop struct {
+	Name string
+	Next *Loop
+}
@@ -0,0 +1,104 @@
+package roundtrip
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRefs(t *testing.T) {
+	a, b, c := &Node{Name: "a"}, &Node{Name: "b"}, &Node{Name: "c"}
+	a.Edges = []*Node{b, c}
+	b.Edges = []*Node{c, a}
+	c.Edges = []*Node{c}
+	count := 3
+
+	in := Graph{Nodes: []*Node{a, b, c}, Root: b, Count: &count, Also: &count}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Graph
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(got.Nodes) != 3 {
+		t.Fatalf("expecting 3 nodes, got %d", len(got.Nodes))
+	}
+
+	ga, gb, gc := got.Nodes[0], got.Nodes[1], got.Nodes[2]
+
+	if ga.Name != "a" || gb.Name != "b" || gc.Name != "c" {
+		t.Errorf("unexpected node names %q, %q, %q", ga.Name, gb.Name, gc.Name)
+	} else if got.Root != gb {
+		t.Error("expecting Root to share the second node")
+	} else if ga.Edges[0] != gb || ga.Edges[1] != gc || gb.Edges[0] != gc || gb.Edges[1] != ga {
+		t.Error("expecting edges to share nodes")
+	} else if gc.Edges[0] != gc {
+		t.Error("expecting self loop to be preserved")
+	} else if got.Count != got.Also || *got.Count != 3 {
+		t.Error("expecting shared int pointer")
+	}
+}
+
+func TestRefsRoot(t *testing.T) {
+	head := &List{Value: 1}
+	head.Next = &List{Value: 2, Prev: head}
+	head.Next.Next = &List{Value: 3, Prev: head.Next}
+
+	data, err := head.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if size := head.BinarySize(); size != len(data) {
+		t.Errorf("expecting BinarySize %d, got %d", len(data), size)
+	}
+
+	var got List
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got.Next == nil || got.Next.Next == nil {
+		t.Fatal("expecting three list entries")
+	} else if got.Prev != nil || got.Next.Prev != &got || got.Next.Next.Prev != got.Next {
+		t.Error("expecting back pointers to reference the decoded list")
+	} else if got.Value != 1 || got.Next.Value != 2 || got.Next.Next.Value != 3 {
+		t.Errorf("unexpected values %d, %d, %d", got.Value, got.Next.Value, got.Next.Next.Value)
+	}
+
+	var buf bytes.Buffer
+
+	if _, err := head.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var stream List
+
+	if _, err := stream.ReadFrom(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if stream.Next == nil || stream.Next.Prev != &stream {
+		t.Error("expecting stream decoding to reference the decoded list")
+	}
+}
+
+func TestRefsSelfLoop(t *testing.T) {
+	in := &Loop{Name: "self"}
+	in.Next = in
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Loop
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got.Next != &got {
+		t.Error("expecting self loop to reference the decoded value")
+	} else if got.Name != "self" {
+		t.Errorf("expecting name %q, got %q", "self", got.Name)
+	}
+}
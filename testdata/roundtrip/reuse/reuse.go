@@ -0,0 +1,9 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -reuse Buffers
+
+type Buffers struct {
+	Data  []byte
+	Words []string
+	Index map[string]uint8
+}
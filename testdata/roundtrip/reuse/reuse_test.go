@@ -0,0 +1,56 @@
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReuse(t *testing.T) {
+	in := Buffers{
+		Data:  []byte{1, 2},
+		Words: []string{"a"},
+		Index: map[string]uint8{"b": 3},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := Buffers{
+		Data:  make([]byte, 4, 8),
+		Words: []string{"x", "y", "z"},
+		Index: map[string]uint8{"stale": 1},
+	}
+
+	dataPtr, wordsPtr, index := &got.Data[:1][0], &got.Words[0], got.Index
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	} else if &got.Data[0] != dataPtr {
+		t.Error("expecting Data to reuse its backing array")
+	} else if &got.Words[0] != wordsPtr {
+		t.Error("expecting Words to reuse its backing array")
+	} else if reflect.ValueOf(got.Index).UnsafePointer() != reflect.ValueOf(index).UnsafePointer() {
+		t.Error("expecting Index to reuse the existing map")
+	}
+}
+
+func TestReuseGrow(t *testing.T) {
+	in := Buffers{Data: []byte{1, 2, 3}}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := Buffers{Data: make([]byte, 1)}
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got.Data, in.Data) {
+		t.Errorf("expecting %v, got %v", in.Data, got.Data)
+	}
+}
@@ -0,0 +1,9 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -reuse -keepnil Nils
+
+type Nils struct {
+	A []int
+	B map[string][]byte
+	C [][]string
+}
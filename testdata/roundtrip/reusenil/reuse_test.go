@@ -0,0 +1,33 @@
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReuseKeepNil(t *testing.T) {
+	for n, test := range [...]struct {
+		in, into Nils
+	}{
+		{Nils{}, Nils{}},
+		{Nils{A: []int{}}, Nils{}},
+		{Nils{A: []int{}}, Nils{A: []int{1, 2}}},
+		{Nils{A: []int{3}}, Nils{A: make([]int, 0, 4)}},
+		{Nils{}, Nils{A: []int{1}, B: map[string][]byte{"a": nil}}},
+		{Nils{B: map[string][]byte{"a": {}, "b": nil, "c": {1}}}, Nils{}},
+		{Nils{C: [][]string{{}, nil, {"d"}}}, Nils{C: [][]string{nil, {"e"}}}},
+	} {
+		data, err := test.in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		got := test.into
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(got, test.in) {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, test.in, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -refs Record Tagged Sparse Graph
+
+import (
+	"math/big"
+	"net/netip"
+	"time"
+)
+
+type Point struct {
+	X, Y int32
+}
+
+//marshal:varint
+type Record struct {
+	ID     uint64
+	Delta  int64
+	Name   string
+	Short  string `marshal:",len8"`
+	Data   []byte
+	Points []Point
+	Counts map[string]int
+	Grid   [2][]uint16
+	Next   *Record
+	When   time.Time
+	Big    *big.Int
+	Addr   netip.Addr
+	Shape  Shape
+}
+
+//marshal:union Circle Square
+type Shape interface {
+	shape()
+}
+
+type Circle struct {
+	R float64
+}
+
+func (Circle) shape() {}
+
+type Square struct {
+	Side float64
+	Name string
+}
+
+func (Square) shape() {}
+
+//marshal:tagged
+type Tagged struct {
+	ID    uint32
+	Name  string `marshal:"3"`
+	Tags  []string
+	Point Point
+}
+
+//marshal:omitzero
+type Sparse struct {
+	A int
+	B string
+	C []Point
+	D map[uint16]string
+}
+
+type Graph struct {
+	Nodes []*Node
+	Root  *Node
+}
+
+type Node struct {
+	Name string
+	Next *Node
+}
@@ -0,0 +1,54 @@
+package roundtrip
+
+import (
+	"encoding"
+	"math/big"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sizer interface {
+	encoding.BinaryMarshaler
+	BinarySize() int
+}
+
+func TestBinarySize(t *testing.T) {
+	shared := &Node{Name: "shared"}
+	cyclic := &Node{Name: "cyclic"}
+	cyclic.Next = cyclic
+
+	for n, test := range [...]sizer{
+		&Record{},
+		&Record{
+			ID:     1 << 40,
+			Delta:  -1 << 33,
+			Name:   strings.Repeat("a", 200),
+			Short:  "short",
+			Data:   make([]byte, 300),
+			Points: []Point{{1, 2}, {3, 4}},
+			Counts: map[string]int{"a": 1, "bb": 1 << 20, "ccc": -5},
+			Grid:   [2][]uint16{{1, 2, 3}, nil},
+			Next:   &Record{Name: "next", Shape: Circle{R: 1}},
+			When:   time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("X", 3600)),
+			Big:    big.NewInt(-1 << 50),
+			Addr:   netip.MustParseAddr("2001:db8::1"),
+			Shape:  Square{Side: 2, Name: "square"},
+		},
+		&Tagged{},
+		&Tagged{ID: 300, Name: strings.Repeat("b", 130), Tags: []string{"x", "y"}, Point: Point{5, 6}},
+		&Sparse{},
+		&Sparse{B: "b", D: map[uint16]string{1: "one", 2: "two"}},
+		&Sparse{A: 1, B: "b", C: []Point{{7, 8}}, D: map[uint16]string{}},
+		&Graph{},
+		&Graph{Nodes: []*Node{shared, shared, {Name: "a", Next: shared}, cyclic}, Root: shared},
+	} {
+		data, err := test.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if size := test.BinarySize(); size != len(data) {
+			t.Errorf("test %d: expecting size %d, got %d", n+1, len(data), size)
+		}
+	}
+}
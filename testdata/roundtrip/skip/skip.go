@@ -0,0 +1,22 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -skipunsupported Handler Pair
+
+type Handler struct {
+	Name     string
+	Callback func() error
+	Events   chan string
+	Retries  uint8
+	Hooks    []Hook
+	Slots    [2]Hook
+}
+
+type Hook struct {
+	Fn func()
+}
+
+type Pair struct {
+	A     uint8
+	Hooks [2]Hook
+	B     uint8
+}
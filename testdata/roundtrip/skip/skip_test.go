@@ -0,0 +1,41 @@
+package roundtrip
+
+import "testing"
+
+func TestSkipUnsupported(t *testing.T) {
+	in := Handler{Name: "handler", Callback: func() error { return nil }, Events: make(chan string), Retries: 3, Hooks: make([]Hook, 2)}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Handler
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got.Name != in.Name || got.Retries != in.Retries {
+		t.Errorf("expecting %q and %d, got %q and %d", in.Name, in.Retries, got.Name, got.Retries)
+	} else if got.Callback != nil || got.Events != nil {
+		t.Error("expecting skipped fields to be left unset")
+	} else if len(got.Hooks) != len(in.Hooks) {
+		t.Errorf("expecting %d hooks, got %d", len(in.Hooks), len(got.Hooks))
+	}
+}
+
+func TestSkipEmptyElements(t *testing.T) {
+	in := Pair{A: 1, B: 2}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Pair
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got.A != in.A || got.B != in.B {
+		t.Errorf("expecting %d and %d, got %d and %d", in.A, in.B, got.A, got.B)
+	}
+}
@@ -0,0 +1,13 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -sortkeys Index
+
+type Point struct {
+	X, Y int8
+}
+
+type Index struct {
+	Names  map[string]uint8
+	Points map[Point]bool
+	Flags  map[bool]uint8
+}
@@ -0,0 +1,65 @@
+package roundtrip
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSortKeys(t *testing.T) {
+	in := Index{
+		Names:  make(map[string]uint8),
+		Points: make(map[Point]bool),
+		Flags:  map[bool]uint8{true: 1, false: 0},
+	}
+
+	for n := range 32 {
+		in.Names[strconv.Itoa(n)] = uint8(n)
+		in.Points[Point{X: int8(n % 4), Y: int8(-n)}] = n%2 == 0
+	}
+
+	first, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for n := range 16 {
+		data, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !bytes.Equal(data, first) {
+			t.Fatalf("test %d: encoding is not deterministic", n+1)
+		}
+	}
+
+	var got Index
+
+	if err := got.UnmarshalBinary(first); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestSortKeysOrder(t *testing.T) {
+	in := Index{
+		Names:  map[string]uint8{"b": 2, "a": 1, "c": 3},
+		Points: map[Point]bool{{1, 0}: true, {0, 2}: false, {0, 1}: true},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expect := []byte{
+		3, 1, 'a', 1, 1, 'b', 2, 1, 'c', 3,
+		3, 0, 1, 1, 0, 2, 0, 1, 0, 1,
+		0,
+	}
+
+	if !bytes.Equal(data, expect) {
+		t.Errorf("expecting bytes %v, got %v", expect, data)
+	}
+}
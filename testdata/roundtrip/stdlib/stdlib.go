@@ -0,0 +1,18 @@
+package roundtrip
+
+import (
+	"math/big"
+	"net/netip"
+	"time"
+)
+
+//go:generate marshal -o marshal.go Values
+
+type Values struct {
+	At      time.Time
+	Timeout time.Duration
+	Addr    netip.Addr
+	Prefix  netip.Prefix
+	Big     *big.Int
+	Total   big.Int
+}
@@ -0,0 +1,61 @@
+package roundtrip
+
+import (
+	"math/big"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestStdlib(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	for n, in := range [...]Values{
+		{Big: new(big.Int)},
+		{
+			At:      time.Date(2024, 2, 29, 12, 30, 45, 999, time.FixedZone("X", -5*3600)),
+			Timeout: 90 * time.Second,
+			Addr:    netip.MustParseAddr("192.0.2.1"),
+			Prefix:  netip.MustParsePrefix("2001:db8::/32"),
+			Big:     huge,
+			Total:   *big.NewInt(42),
+		},
+		{
+			At:     time.Now(),
+			Addr:   netip.MustParseAddr("fe80::1%eth0"),
+			Prefix: netip.MustParsePrefix("10.0.0.0/8"),
+			Big:    big.NewInt(-1),
+		},
+	} {
+		data, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		var got Values
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !got.At.Equal(in.At) {
+			t.Errorf("test %d: expecting time %s, got %s", n+1, in.At, got.At)
+		} else if _, offset := got.At.Zone(); offset != zoneOffset(in.At) {
+			t.Errorf("test %d: expecting zone offset %d, got %d", n+1, zoneOffset(in.At), offset)
+		} else if got.Timeout != in.Timeout {
+			t.Errorf("test %d: expecting duration %s, got %s", n+1, in.Timeout, got.Timeout)
+		} else if got.Addr != in.Addr {
+			t.Errorf("test %d: expecting addr %s, got %s", n+1, in.Addr, got.Addr)
+		} else if got.Prefix != in.Prefix {
+			t.Errorf("test %d: expecting prefix %s, got %s", n+1, in.Prefix, got.Prefix)
+		} else if got.Big.Cmp(in.Big) != 0 {
+			t.Errorf("test %d: expecting big %s, got %s", n+1, in.Big, got.Big)
+		} else if got.Total.Cmp(&in.Total) != 0 {
+			t.Errorf("test %d: expecting total %s, got %s", n+1, &in.Total, &got.Total)
+		}
+	}
+}
+
+func zoneOffset(t time.Time) int {
+	_, offset := t.Zone()
+
+	return offset
+}
@@ -0,0 +1,20 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Packet Point Lenient
+
+//marshal:strict
+type Packet struct {
+	Kind uint8
+	Body string
+	Tags []uint16
+}
+
+//marshal:strict
+type Point struct {
+	X, Y int32
+}
+
+type Lenient struct {
+	Kind uint8
+	Body string
+}
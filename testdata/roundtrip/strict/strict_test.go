@@ -0,0 +1,60 @@
+package roundtrip
+
+import (
+	"encoding"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	for n, test := range [...]struct {
+		in, out interface {
+			encoding.BinaryMarshaler
+			encoding.BinaryUnmarshaler
+		}
+	}{
+		{&Packet{Kind: 1, Body: "body", Tags: []uint16{1, 2}}, new(Packet)},
+		{&Point{X: 1, Y: -1}, new(Point)},
+	} {
+		data, err := test.in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		if err := test.out.UnmarshalBinary(data); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		if err := test.out.UnmarshalBinary(append(data, 0)); !errors.Is(err, ErrTrailingBytes) {
+			t.Errorf("test %d: expecting ErrTrailingBytes, got %v", n+1, err)
+		}
+
+		for l := range len(data) {
+			if err := test.out.UnmarshalBinary(data[:l]); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("test %d.%d: expecting io.ErrUnexpectedEOF, got %v", n+1, l, err)
+			}
+		}
+	}
+}
+
+func TestLenient(t *testing.T) {
+	data, err := (&Lenient{Kind: 1, Body: "body"}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Lenient
+
+	if err := got.UnmarshalBinary(append(data, 1, 2, 3)); err != nil {
+		t.Errorf("unexpected error with trailing bytes: %s", err)
+	} else if got.Kind != 1 || got.Body != "body" {
+		t.Errorf("unexpected value %#v", got)
+	}
+
+	if err := got.UnmarshalBinary(data[:1]); err != nil {
+		t.Errorf("unexpected error with short input: %s", err)
+	} else if got.Kind != 1 || got.Body != "" {
+		t.Errorf("expecting missing fields to be zero, got %#v", got)
+	}
+}
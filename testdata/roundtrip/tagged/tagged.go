@@ -0,0 +1,35 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -tagged Message MessageV1 Envelope EnvelopeV1
+
+type MessageV1 struct {
+	ID   uint32
+	Text string
+}
+
+type Message struct {
+	ID      uint32
+	Text    string
+	Retired int64    `marshal:"-"`
+	Labels  []string `marshal:"4"`
+	Score   float64
+}
+
+type PartV1 struct {
+	Name string
+}
+
+type Part struct {
+	Name string
+	Size uint32
+}
+
+type EnvelopeV1 struct {
+	Parts []PartV1
+	Note  string
+}
+
+type Envelope struct {
+	Parts []Part
+	Note  string
+}
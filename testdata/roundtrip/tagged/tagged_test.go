@@ -0,0 +1,68 @@
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTaggedRoundTrip(t *testing.T) {
+	in := Message{ID: 1, Text: "text", Labels: []string{"a", "b"}, Score: 0.5}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Message
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestTaggedOldReader(t *testing.T) {
+	data, err := (&Message{ID: 2, Text: "new", Labels: []string{"x"}, Score: 1}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got MessageV1
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := (MessageV1{ID: 2, Text: "new"}); got != expect {
+		t.Errorf("expecting %#v, got %#v", expect, got)
+	}
+}
+
+func TestTaggedNewReader(t *testing.T) {
+	data, err := (&MessageV1{ID: 3, Text: "old"}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := Message{Labels: []string{"stale"}, Score: 9}
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := (Message{ID: 3, Text: "old"}); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expecting %#v, got %#v", expect, got)
+	}
+}
+
+func TestTaggedNestedOldReader(t *testing.T) {
+	data, err := (&Envelope{Parts: []Part{{Name: "a", Size: 1}, {Name: "b", Size: 2}}, Note: "note"}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got EnvelopeV1
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := (EnvelopeV1{Parts: []PartV1{{Name: "a"}, {Name: "b"}}, Note: "note"}); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expecting %#v, got %#v", expect, got)
+	}
+}
@@ -0,0 +1,12 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Record
+
+type Record struct {
+	Cache  string `marshal:"-"`
+	Second uint8  `marshal:"2"`
+	First  uint8  `marshal:"1"`
+	Name   string `marshal:",len8"`
+	Count  int64  `marshal:",varint"`
+	Wide   string `marshal:",len16"`
+}
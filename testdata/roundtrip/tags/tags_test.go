@@ -0,0 +1,29 @@
+package roundtrip
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	in := Record{Cache: "cached", Second: 2, First: 1, Name: "ab", Count: -2, Wide: "c"}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := []byte{1, 2, 2, 'a', 'b', 3, 1, 0, 'c'}; !bytes.Equal(data, expect) {
+		t.Errorf("expecting bytes %v, got %v", expect, data)
+	}
+
+	got := Record{Cache: "kept"}
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	in.Cache = "kept"
+
+	if got != in {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
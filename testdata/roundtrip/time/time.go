@@ -0,0 +1,9 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Event
+
+import "time"
+
+type Event struct {
+	At time.Time
+}
@@ -0,0 +1,39 @@
+package roundtrip
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestEventRoundTrip(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Unix(1700000000, 123456789)
+
+	for n, at := range [...]time.Time{
+		base.UTC(),
+		base.In(ny),
+		base.In(time.FixedZone("XYZ", 3600)),
+	} {
+		data, err := (&Event{At: at}).MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		var first, second Event
+
+		if err := first.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if err := second.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if first.At.String() != at.String() {
+			t.Errorf("test %d: expecting time %s, got %s", n+1, at, first.At)
+		} else if at.Location() == ny && first.At.Location() != second.At.Location() {
+			t.Errorf("test %d: expecting decoded locations to be shared", n+1)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Private Public Hidden
+
+//marshal:unexported
+type Private struct {
+	Name  string
+	count uint16
+	tags  []string
+}
+
+type Public struct {
+	Name  string
+	count uint16
+}
+
+//marshal:unexported
+type Hidden struct {
+	id uint8
+}
@@ -0,0 +1,59 @@
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnexported(t *testing.T) {
+	in := Private{Name: "a", count: 2, tags: []string{"b"}}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Private
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestUnexportedSkipped(t *testing.T) {
+	in := Public{Name: "a", count: 2}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Public
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expected := (Public{Name: "a"}); got != expected {
+		t.Errorf("expecting %#v, got %#v", expected, got)
+	}
+}
+
+func TestUnexportedFixed(t *testing.T) {
+	in := Hidden{id: 7}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(data) != 1 || data[0] != 7 {
+		t.Fatalf("expecting [7], got %v", data)
+	}
+
+	var got Hidden
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != in {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
@@ -0,0 +1,29 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Log
+
+//marshal:union Created *Deleted
+type Event interface {
+	event()
+}
+
+type Created struct {
+	ID   uint32
+	Name string
+}
+
+func (Created) event() {}
+
+type Deleted struct {
+	ID uint32
+}
+
+func (*Deleted) event() {}
+
+type Renamed struct{}
+
+func (Renamed) event() {}
+
+type Log struct {
+	Events []Event
+}
@@ -0,0 +1,38 @@
+package roundtrip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnion(t *testing.T) {
+	in := Log{Events: []Event{Created{ID: 1, Name: "a"}, &Deleted{ID: 1}, nil, Created{ID: 2}}}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Log
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestUnionErrors(t *testing.T) {
+	in := Log{Events: []Event{Renamed{}}}
+
+	if _, err := in.MarshalBinary(); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expecting ErrUnsupported for a non-member, got %v", err)
+	}
+
+	var got Log
+
+	if err := got.UnmarshalBinary([]byte{1, 9}); err == nil {
+		t.Error("expecting error for an unknown member")
+	}
+}
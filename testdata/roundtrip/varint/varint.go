@@ -0,0 +1,16 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -varint Counters Fixed
+
+type Counters struct {
+	Signed   int64
+	Unsigned uint32
+	Small    int
+	Pinned   uint16 `marshal:",fixed"`
+}
+
+//marshal:fixed
+type Fixed struct {
+	Value int32
+	Count uint64 `marshal:",varint"`
+}
@@ -0,0 +1,73 @@
+package roundtrip
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVarint(t *testing.T) {
+	for n, test := range [...]struct {
+		in     Counters
+		expect []byte
+	}{
+		{Counters{}, []byte{0, 0, 0, 0, 0}},
+		{Counters{Signed: -1, Unsigned: 1, Small: 1, Pinned: 1}, []byte{1, 1, 2, 1, 0}},
+		{Counters{Signed: 64, Unsigned: 128, Small: -64, Pinned: 0x0102}, []byte{0x80, 0x01, 0x80, 0x01, 0x7f, 2, 1}},
+	} {
+		data, err := test.in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !bytes.Equal(data, test.expect) {
+			t.Errorf("test %d: expecting bytes %v, got %v", n+1, test.expect, data)
+		}
+
+		var got Counters
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(got, test.in) {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, test.in, got)
+		}
+	}
+}
+
+func TestVarintRange(t *testing.T) {
+	for n, in := range [...]Counters{
+		{Signed: math.MinInt64, Unsigned: math.MaxUint32, Small: math.MaxInt},
+		{Signed: math.MaxInt64, Small: math.MinInt},
+	} {
+		data, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		var got Counters
+
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		} else if got != in {
+			t.Errorf("test %d: expecting %#v, got %#v", n+1, in, got)
+		}
+	}
+}
+
+func TestFixedDirective(t *testing.T) {
+	in := Fixed{Value: 1, Count: 300}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := []byte{1, 0, 0, 0, 0xac, 0x02}; !bytes.Equal(data, expect) {
+		t.Errorf("expecting bytes %v, got %v", expect, data)
+	}
+
+	var got Fixed
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != in {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
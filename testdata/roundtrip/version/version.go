@@ -0,0 +1,22 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go Account AccountV1 AccountV3
+
+//marshal:version 1
+type AccountV1 struct {
+	Name    string
+	Balance int32
+}
+
+//marshal:version 2
+type Account struct {
+	Name    string
+	Balance int32  `marshal:",removed=2"`
+	Cents   int64  `marshal:",since=2"`
+	Email   string `marshal:",since=2"`
+}
+
+//marshal:version 3
+type AccountV3 struct {
+	Name string
+}
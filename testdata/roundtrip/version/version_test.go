@@ -0,0 +1,54 @@
+package roundtrip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVersionRoundTrip(t *testing.T) {
+	in := Account{Name: "name", Cents: 150, Email: "a@example.com"}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if data[0] != 2 {
+		t.Errorf("expecting version 2 header, got %d", data[0])
+	}
+
+	got := Account{Balance: 9}
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if in.Balance = 0; !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	}
+}
+
+func TestVersionUpgrade(t *testing.T) {
+	data, err := (&AccountV1{Name: "old", Balance: 7}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := Account{Cents: 1, Email: "stale"}
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if expect := (Account{Name: "old", Balance: 7}); got != expect {
+		t.Errorf("expecting %#v, got %#v", expect, got)
+	}
+}
+
+func TestVersionUnsupported(t *testing.T) {
+	data, err := (&AccountV3{Name: "new"}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Account
+
+	if err := got.UnmarshalBinary(data); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expecting ErrUnsupportedVersion, got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package roundtrip
+
+//go:generate marshal -o marshal.go -zerocopy Entry
+
+type Entry struct {
+	Key   string
+	Value []byte
+	Tags  []string
+}
@@ -0,0 +1,57 @@
+package roundtrip
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func within(data []byte, p *byte) bool {
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(data)))
+	addr := uintptr(unsafe.Pointer(p))
+
+	return addr >= start && addr < start+uintptr(len(data))
+}
+
+func TestZeroCopy(t *testing.T) {
+	in := Entry{Key: "key", Value: []byte("value"), Tags: []string{"a", "bc"}}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Entry
+
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, in) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	} else if !within(data, unsafe.StringData(got.Key)) {
+		t.Error("expecting Key to alias the input")
+	} else if !within(data, unsafe.SliceData(got.Value)) {
+		t.Error("expecting Value to alias the input")
+	} else if !within(data, unsafe.StringData(got.Tags[1])) {
+		t.Error("expecting Tags to alias the input")
+	}
+}
+
+func TestZeroCopyStream(t *testing.T) {
+	in := Entry{Key: "key", Value: []byte("value")}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got Entry
+
+	if _, err := got.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got.Key != in.Key || !bytes.Equal(got.Value, in.Value) {
+		t.Errorf("expecting %#v, got %#v", in, got)
+	} else if within(data, unsafe.SliceData(got.Value)) {
+		t.Error("expecting stream decoding to copy")
+	}
+}
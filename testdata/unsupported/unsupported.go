@@ -0,0 +1,30 @@
+package unsupported
+
+import "unsafe"
+
+type Chan struct {
+	C chan int
+}
+
+type Func struct {
+	Inner struct {
+		F func()
+	}
+}
+
+type Slice struct {
+	Items []Ptr
+}
+
+type Ptr struct {
+	P unsafe.Pointer
+}
+
+type Map struct {
+	M map[string]any
+}
+
+type Tagged struct {
+	C chan int `marshal:"-"`
+	N int
+}
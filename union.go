@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+func (c *constructor) unionMembers(typ types.Type, iface *types.Interface) []types.Type {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() != c.pkg {
+		return nil
+	}
+
+	var members []types.Type
+
+	for _, directive := range c.dirs[named.Obj().Name()] {
+		name, args, _ := strings.Cut(directive, " ")
+		if name != "union" {
+			continue
+		}
+
+		for _, member := range strings.Fields(args) {
+			typeName, ptr := strings.CutPrefix(member, "*")
+
+			obj, ok := c.pkg.Scope().Lookup(typeName).(*types.TypeName)
+			if !ok {
+				c.setError(fmt.Errorf("%w: %s: %s", ErrInvalidUnion, named.Obj().Name(), member))
+
+				return nil
+			}
+
+			mtyp := obj.Type()
+			if ptr {
+				mtyp = types.NewPointer(mtyp)
+			}
+
+			if !types.Implements(mtyp, iface) {
+				c.setError(fmt.Errorf("%w: %s: %s does not implement the interface", ErrInvalidUnion, named.Obj().Name(), member))
+
+				return nil
+			}
+
+			members = append(members, mtyp)
+		}
+	}
+
+	return members
+}
+
+func (c *constructor) memberIdent(typ types.Type) ast.Expr {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		return &ast.StarExpr{
+			X: c.accessibleIdent(ptr.Elem()),
+		}
+	}
+
+	return c.accessibleIdent(typ)
+}
+
+func (c *constructor) writeUnion(name ast.Expr, typ types.Type, t *types.Interface) {
+	members := c.unionMembers(typ, t)
+	if len(members) == 0 {
+		c.unsupported(typ)
+
+		return
+	}
+
+	c.use("errors")
+	c.use("fmt")
+
+	u := c.varName("u")
+	none := c.subConstructor()
+	used := !c.sizing
+
+	none.addWriter("WriteUintX", &ast.BasicLit{
+		Kind:  token.INT,
+		Value: "0",
+	})
+
+	clauses := []ast.Stmt{
+		&ast.CaseClause{
+			List: []ast.Expr{
+				ast.NewIdent("nil"),
+			},
+			Body: none.statements,
+		},
+	}
+
+	for n, member := range members {
+		d := c.subConstructor()
+
+		d.addWriter("WriteUintX", &ast.BasicLit{
+			Kind:  token.INT,
+			Value: strconv.Itoa(n + 1),
+		})
+		d.writeType(u, member)
+
+		if _, fixed := c.fixedSize(member); !fixed {
+			used = true
+		}
+
+		clauses = append(clauses, &ast.CaseClause{
+			List: []ast.Expr{
+				c.memberIdent(member),
+			},
+			Body: d.statements,
+		})
+	}
+
+	var assign ast.Stmt = &ast.AssignStmt{
+		Lhs: []ast.Expr{u},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.TypeAssertExpr{
+				X: name,
+			},
+		},
+	}
+
+	if !used {
+		assign = &ast.ExprStmt{
+			X: &ast.TypeAssertExpr{
+				X: name,
+			},
+		}
+	}
+
+	if !c.sizing {
+		clauses = append(clauses, &ast.CaseClause{
+			Body: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent("fmt"),
+								Sel: ast.NewIdent("Errorf"),
+							},
+							Args: []ast.Expr{
+								&ast.BasicLit{
+									Kind:  token.STRING,
+									Value: strconv.Quote("%w: %T is not a member of the " + typ.(*types.Named).Obj().Name() + " union"),
+								},
+								&ast.SelectorExpr{
+									X:   ast.NewIdent("errors"),
+									Sel: ast.NewIdent("ErrUnsupported"),
+								},
+								u,
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	c.addStatement(&ast.TypeSwitchStmt{
+		Switch: c.newLine(),
+		Assign: assign,
+		Body: &ast.BlockStmt{
+			List: clauses,
+		},
+	})
+}
+
+func (c *constructor) readUnion(name ast.Expr, typ types.Type, t *types.Interface) {
+	members := c.unionMembers(typ, t)
+	if len(members) == 0 {
+		c.unsupported(typ)
+
+		return
+	}
+
+	c.use("fmt")
+
+	u := c.varName("u")
+	clauses := []ast.Stmt{
+		&ast.CaseClause{
+			List: []ast.Expr{
+				&ast.BasicLit{
+					Kind:  token.INT,
+					Value: "0",
+				},
+			},
+			Body: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{name},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						ast.NewIdent("nil"),
+					},
+				},
+			},
+		},
+	}
+
+	for n, member := range members {
+		d := c.subConstructor()
+
+		d.addStatement(&ast.DeclStmt{
+			Decl: &ast.GenDecl{
+				Tok: token.VAR,
+				Specs: []ast.Spec{
+					&ast.ValueSpec{
+						Names: []*ast.Ident{u},
+						Type:  c.memberIdent(member),
+					},
+				},
+			},
+		})
+		d.readType(u, member)
+
+		c.unchecked = c.unchecked || d.unchecked
+
+		d.addStatement(&ast.AssignStmt{
+			Lhs: []ast.Expr{name},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{u},
+		})
+
+		clauses = append(clauses, &ast.CaseClause{
+			List: []ast.Expr{
+				&ast.BasicLit{
+					Kind:  token.INT,
+					Value: strconv.Itoa(n + 1),
+				},
+			},
+			Body: d.statements,
+		})
+	}
+
+	c.addStatement(&ast.SwitchStmt{
+		Switch: c.newLine(),
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{u},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				readCall("ReadUintX"),
+			},
+		},
+		Tag: u,
+		Body: &ast.BlockStmt{
+			List: append(clauses, &ast.CaseClause{
+				Body: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							c.decodeError(&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   ast.NewIdent("fmt"),
+									Sel: ast.NewIdent("Errorf"),
+								},
+								Args: []ast.Expr{
+									&ast.BasicLit{
+										Kind:  token.STRING,
+										Value: strconv.Quote("unknown member %d of the " + typ.(*types.Named).Obj().Name() + " union"),
+									},
+									u,
+								},
+							}),
+						},
+					},
+				},
+			}),
+		},
+	})
+}
@@ -4,21 +4,33 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
-	"strings"
+	"strconv"
 )
 
 func unmarshalName(typ *types.Named) string {
-	return "_unmarshal_" + strings.ReplaceAll(strings.ReplaceAll(typ.Obj().Name(), "_", "__"), ".", "_")
+	return "_unmarshal_" + funcSuffix(typ)
 }
 
-func (c *constructor) unmarshalBinary(typeName, funcName, unmarshalName string) *ast.FuncDecl {
+func (c *constructor) unmarshalBinary(typeName ast.Expr, funcName, unmarshalName string) *ast.FuncDecl {
 	comment := "// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface."
 
 	if funcName != "UnmarshalBinary" {
 		comment = "// " + funcName + " decodes the receiver from the binary form."
 	}
 
-	return &ast.FuncDecl{
+	comment += "\n//\n// The data is decoded using " + c.endianComment() + " byte order."
+
+	if c.strict {
+		comment += "\n//\n// An error is returned if the data is too short or if any bytes remain after decoding."
+	} else {
+		comment += "\n//\n// Missing data is decoded as zero values and any bytes remaining after decoding are ignored."
+	}
+
+	if c.zeroCopy {
+		comment += "\n//\n// Decoded strings and byte slices refer to b instead of copying it, so b must not be\n// modified while the decoded value is in use."
+	}
+
+	decl := &ast.FuncDecl{
 		Doc: &ast.CommentGroup{
 			List: []*ast.Comment{
 				{
@@ -35,7 +47,7 @@ func (c *constructor) unmarshalBinary(typeName, funcName, unmarshalName string)
 					},
 					Type: &ast.UnaryExpr{
 						Op: token.MUL,
-						X:  ast.NewIdent(typeName),
+						X:  typeName,
 					},
 				},
 			},
@@ -62,52 +74,98 @@ func (c *constructor) unmarshalBinary(typeName, funcName, unmarshalName string)
 				},
 			},
 		},
-		Body: &ast.BlockStmt{
-			List: []ast.Stmt{
-				&ast.AssignStmt{
-					Lhs: []ast.Expr{
-						ast.NewIdent("eb"),
+		Body: &ast.BlockStmt{},
+	}
+
+	if unmarshalName == "" {
+		return decl
+	}
+
+	body := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("eb"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent("byteio"),
+						Sel: ast.NewIdent("Mem" + c.endian()),
 					},
-					Tok: token.DEFINE,
-					Rhs: []ast.Expr{
-						&ast.CallExpr{
-							Fun: &ast.SelectorExpr{
-								X:   ast.NewIdent("byteio"),
-								Sel: ast.NewIdent("MemLittleEndian"),
-							},
-							Args: []ast.Expr{
-								ast.NewIdent("b"),
-							},
-						},
+					Args: []ast.Expr{
+						ast.NewIdent("b"),
 					},
 				},
-				&ast.ReturnStmt{
-					Return: c.newLine(),
-					Results: []ast.Expr{
-						&ast.CallExpr{
-							Fun: ast.NewIdent(unmarshalName),
-							Args: []ast.Expr{
-								ast.NewIdent("t"),
-								&ast.UnaryExpr{
-									Op: token.AND,
-									X:  ast.NewIdent("eb"),
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: ast.NewIdent(unmarshalName),
+					Args: c.newRefsArgs(c.newLimitArgs(
+						ast.NewIdent("t"),
+						&ast.UnaryExpr{
+							Op: token.AND,
+							X:  ast.NewIdent("eb"),
+						},
+					)...),
+				},
+			},
+		},
+		&ast.ReturnStmt{
+			Return: c.newLine(),
+			Results: []ast.Expr{
+				c.decodeDone(ast.NewIdent("err"), &ast.CallExpr{
+					Fun: ast.NewIdent("int64"),
+					Args: []ast.Expr{
+						&ast.BinaryExpr{
+							X: &ast.CallExpr{
+								Fun: ast.NewIdent("len"),
+								Args: []ast.Expr{
+									ast.NewIdent("b"),
+								},
+							},
+							Op: token.SUB,
+							Y: &ast.CallExpr{
+								Fun: ast.NewIdent("len"),
+								Args: []ast.Expr{
+									ast.NewIdent("eb"),
 								},
 							},
 						},
 					},
-				},
+				}),
 			},
 		},
 	}
+
+	if c.strict {
+		body = c.strictBody(unmarshalName)
+	}
+
+	decl.Body.List = body
+
+	return decl
 }
 
-func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.FuncDecl {
+func (c *constructor) readFrom(typeName ast.Expr, funcName, unmarshalName string) *ast.FuncDecl {
 	comment := "// ReadFrom implements the io.ReaderFrom interface."
 
 	if funcName != "ReadFrom" {
 		comment = "// " + funcName + " reads data from r until the type is fully decoded.\n//\n// The return value n is the number of bytes read. Any error encountered during the read is also returned."
 	}
 
+	comment += "\n//\n// Unless r is a byteio reader with its own byte order, the data is decoded using " + c.endianComment() + " byte order."
+
+	if c.zeroCopy {
+		comment += "\n//\n// When r is a byteio.MemLittleEndian or byteio.MemBigEndian, decoded strings and byte\n// slices refer to its buffer instead of copying it."
+	}
+
 	return &ast.FuncDecl{
 		Doc: &ast.CommentGroup{
 			List: []*ast.Comment{
@@ -125,7 +183,7 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 					},
 					Type: &ast.UnaryExpr{
 						Op: token.MUL,
-						X:  ast.NewIdent(typeName),
+						X:  typeName,
 					},
 				},
 			},
@@ -210,21 +268,26 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: ast.NewIdent(unmarshalName),
-												Args: []ast.Expr{
+												Args: c.newRefsArgs(c.newLimitArgs(
 													ast.NewIdent("t"),
 													ast.NewIdent("r"),
-												},
+												)...),
 											},
 										},
 									},
-									&ast.ReturnStmt{
-										Return: c.newLine(),
-										Results: []ast.Expr{
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											ast.NewIdent("n"),
+										},
+										Tok: token.DEFINE,
+										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: ast.NewIdent("int64"),
 												Args: []ast.Expr{
 													&ast.BinaryExpr{
-														X: &ast.CallExpr{
+														X:  ast.NewIdent("l"),
+														Op: token.SUB,
+														Y: &ast.CallExpr{
 															Fun: ast.NewIdent("len"),
 															Args: []ast.Expr{
 																&ast.UnaryExpr{
@@ -233,12 +296,16 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 																},
 															},
 														},
-														Op: token.SUB,
-														Y:  ast.NewIdent("l"),
 													},
 												},
 											},
-											ast.NewIdent("err"),
+										},
+									},
+									&ast.ReturnStmt{
+										Return: c.newLine(),
+										Results: []ast.Expr{
+											ast.NewIdent("n"),
+											c.decodeDone(ast.NewIdent("err"), ast.NewIdent("n")),
 										},
 									},
 								},
@@ -279,21 +346,26 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: ast.NewIdent(unmarshalName),
-												Args: []ast.Expr{
+												Args: c.newRefsArgs(c.newLimitArgs(
 													ast.NewIdent("t"),
 													ast.NewIdent("r"),
-												},
+												)...),
 											},
 										},
 									},
-									&ast.ReturnStmt{
-										Return: c.newLine(),
-										Results: []ast.Expr{
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											ast.NewIdent("n"),
+										},
+										Tok: token.DEFINE,
+										Rhs: []ast.Expr{
 											&ast.CallExpr{
 												Fun: ast.NewIdent("int64"),
 												Args: []ast.Expr{
 													&ast.BinaryExpr{
-														X: &ast.CallExpr{
+														X:  ast.NewIdent("l"),
+														Op: token.SUB,
+														Y: &ast.CallExpr{
 															Fun: ast.NewIdent("len"),
 															Args: []ast.Expr{
 																&ast.UnaryExpr{
@@ -302,12 +374,16 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 																},
 															},
 														},
-														Op: token.SUB,
-														Y:  ast.NewIdent("l"),
 													},
 												},
 											},
-											ast.NewIdent("err"),
+										},
+									},
+									&ast.ReturnStmt{
+										Return: c.newLine(),
+										Results: []ast.Expr{
+											ast.NewIdent("n"),
+											c.decodeDone(ast.NewIdent("err"), ast.NewIdent("n")),
 										},
 									},
 								},
@@ -337,10 +413,7 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 									},
 									&ast.AssignStmt{
 										Lhs: []ast.Expr{
-											&ast.SelectorExpr{
-												X:   ast.NewIdent("r"),
-												Sel: ast.NewIdent("Err"),
-											},
+											ast.NewIdent("err"),
 										},
 										Tok: token.DEFINE,
 										Rhs: []ast.Expr{
@@ -350,21 +423,40 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 													Sel: ast.NewIdent("Or"),
 												},
 												Args: []ast.Expr{
-													&ast.SelectorExpr{
-														X:   ast.NewIdent("r"),
-														Sel: ast.NewIdent("Err"),
-													},
 													&ast.CallExpr{
 														Fun: ast.NewIdent(unmarshalName),
-														Args: []ast.Expr{
+														Args: c.newRefsArgs(c.newLimitArgs(
 															ast.NewIdent("t"),
 															ast.NewIdent("r"),
-														},
+														)...),
+													},
+													&ast.SelectorExpr{
+														X:   ast.NewIdent("r"),
+														Sel: ast.NewIdent("Err"),
 													},
 												},
 											},
 										},
 									},
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											&ast.SelectorExpr{
+												X:   ast.NewIdent("r"),
+												Sel: ast.NewIdent("Err"),
+											},
+										},
+										Tok: token.ASSIGN,
+										Rhs: []ast.Expr{
+											c.decodeDone(ast.NewIdent("err"), &ast.BinaryExpr{
+												X: &ast.SelectorExpr{
+													X:   ast.NewIdent("r"),
+													Sel: ast.NewIdent("Count"),
+												},
+												Op: token.SUB,
+												Y:  ast.NewIdent("l"),
+											}),
+										},
+									},
 									&ast.ReturnStmt{
 										Return: c.newLine(),
 										Results: []ast.Expr{
@@ -376,7 +468,10 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 												Op: token.SUB,
 												Y:  ast.NewIdent("l"),
 											},
-											ast.NewIdent("err"),
+											&ast.SelectorExpr{
+												X:   ast.NewIdent("r"),
+												Sel: ast.NewIdent("Err"),
+											},
 										},
 									},
 								},
@@ -406,10 +501,7 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 									},
 									&ast.AssignStmt{
 										Lhs: []ast.Expr{
-											&ast.SelectorExpr{
-												X:   ast.NewIdent("r"),
-												Sel: ast.NewIdent("Err"),
-											},
+											ast.NewIdent("err"),
 										},
 										Tok: token.DEFINE,
 										Rhs: []ast.Expr{
@@ -419,21 +511,40 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 													Sel: ast.NewIdent("Or"),
 												},
 												Args: []ast.Expr{
-													&ast.SelectorExpr{
-														X:   ast.NewIdent("r"),
-														Sel: ast.NewIdent("Err"),
-													},
 													&ast.CallExpr{
 														Fun: ast.NewIdent(unmarshalName),
-														Args: []ast.Expr{
+														Args: c.newRefsArgs(c.newLimitArgs(
 															ast.NewIdent("t"),
 															ast.NewIdent("r"),
-														},
+														)...),
+													},
+													&ast.SelectorExpr{
+														X:   ast.NewIdent("r"),
+														Sel: ast.NewIdent("Err"),
 													},
 												},
 											},
 										},
 									},
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											&ast.SelectorExpr{
+												X:   ast.NewIdent("r"),
+												Sel: ast.NewIdent("Err"),
+											},
+										},
+										Tok: token.ASSIGN,
+										Rhs: []ast.Expr{
+											c.decodeDone(ast.NewIdent("err"), &ast.BinaryExpr{
+												X: &ast.SelectorExpr{
+													X:   ast.NewIdent("r"),
+													Sel: ast.NewIdent("Count"),
+												},
+												Op: token.SUB,
+												Y:  ast.NewIdent("l"),
+											}),
+										},
+									},
 									&ast.ReturnStmt{
 										Return: c.newLine(),
 										Results: []ast.Expr{
@@ -445,7 +556,10 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 												Op: token.SUB,
 												Y:  ast.NewIdent("l"),
 											},
-											ast.NewIdent("err"),
+											&ast.SelectorExpr{
+												X:   ast.NewIdent("r"),
+												Sel: ast.NewIdent("Err"),
+											},
 										},
 									},
 								},
@@ -465,7 +579,7 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 						&ast.CompositeLit{
 							Type: &ast.SelectorExpr{
 								X:   ast.NewIdent("byteio"),
-								Sel: ast.NewIdent("StickyLittleEndianReader"),
+								Sel: ast.NewIdent("Sticky" + c.endian() + "Reader"),
 							},
 							Elts: []ast.Expr{
 								&ast.KeyValueExpr{
@@ -490,13 +604,13 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 							Args: []ast.Expr{
 								&ast.CallExpr{
 									Fun: ast.NewIdent(unmarshalName),
-									Args: []ast.Expr{
+									Args: c.newRefsArgs(c.newLimitArgs(
 										ast.NewIdent("t"),
 										&ast.UnaryExpr{
 											Op: token.AND,
 											X:  ast.NewIdent("sr"),
 										},
-									},
+									)...),
 								},
 								&ast.SelectorExpr{
 									X:   ast.NewIdent("sr"),
@@ -513,7 +627,10 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 							X:   ast.NewIdent("sr"),
 							Sel: ast.NewIdent("Count"),
 						},
-						ast.NewIdent("err"),
+						c.decodeDone(ast.NewIdent("err"), &ast.SelectorExpr{
+							X:   ast.NewIdent("sr"),
+							Sel: ast.NewIdent("Count"),
+						}),
 					},
 				},
 			},
@@ -522,11 +639,48 @@ func (c *constructor) readFrom(typeName, funcName, unmarshalName string) *ast.Fu
 }
 
 func (c *constructor) readType(name ast.Expr, typ types.Type) {
+	if named, ok := typ.(*types.Named); ok {
+		if fn := stdlibFunc(named); fn != "" {
+			c.callHelper("_unmarshal_"+fn, "r", name)
+
+			return
+		} else if names, ok := c.callable(named); ok {
+			c.callFunc(names[1], "r", name)
+
+			return
+		} else if fn := binaryFunc(named, false); fn != "" {
+			c.callHelper(fn, "r", name)
+
+			return
+		}
+
+		c.inlining[named.Origin()] = true
+
+		defer delete(c.inlining, named.Origin())
+	} else if tp, ok := typ.(*types.TypeParam); ok {
+		c.callParam(false, name, tp)
+
+		return
+	}
+
+	c.readUnderlying(name, typ)
+}
+
+func (c *constructor) readUnderlying(name ast.Expr, typ types.Type) {
 	switch t := typ.Underlying().(type) {
 	case *types.Struct:
 		c.readStruct(name, t)
 	case *types.Array:
 		c.readArray(name, t)
+	default:
+		c.unchecked = true
+
+		c.readReference(name, typ)
+	}
+}
+
+func (c *constructor) readReference(name ast.Expr, typ types.Type) {
+	switch t := typ.Underlying().(type) {
 	case *types.Slice:
 		c.readSlice(name, t)
 	case *types.Map:
@@ -534,7 +688,11 @@ func (c *constructor) readType(name ast.Expr, typ types.Type) {
 	case *types.Pointer:
 		c.readPointer(name, t)
 	case *types.Basic:
-		c.readBasic(name, t)
+		c.readBasic(name, typ, t)
+	case *types.Interface:
+		c.readUnion(name, typ, t)
+	default:
+		c.unsupported(typ)
 	}
 }
 
@@ -547,44 +705,94 @@ func (c *constructor) addCall(fun *ast.SelectorExpr, name ast.Expr) {
 	})
 }
 
-func (c *constructor) addReader(method string, name ast.Expr) {
+func (c *constructor) addReader(method string, name ast.Expr, typ types.Type, kind types.BasicKind) {
 	c.addStatement(&ast.AssignStmt{
 		Lhs: []ast.Expr{name},
 		Tok: token.ASSIGN,
 		Rhs: []ast.Expr{
-			&ast.CallExpr{
+			c.convertTo(&ast.CallExpr{
 				Fun: &ast.SelectorExpr{
 					X:   ast.NewIdent("r"),
 					Sel: ast.NewIdent(method),
 				},
-			},
+			}, typ, kind),
 		},
 	})
 }
 
 func (c *constructor) readStruct(name ast.Expr, t *types.Struct) {
-	for field := range t.Fields() {
-		if !field.Exported() {
+	if star, ok := name.(*ast.StarExpr); ok {
+		name = star.X
+	}
+
+	fields, err := c.structFields(t)
+	if err != nil {
+		c.setError(err)
+
+		return
+	}
+
+	conf := c.config
+	path := c.path
+	errPath := c.errPath
+
+	for _, field := range fields {
+		if c.config, err = conf.field(field); err != nil {
+			c.setError(err)
+		} else if c.skip && !c.supported(field.Type(), nil) {
 			continue
 		}
 
-		c.readType(&ast.SelectorExpr{
+		c.path = path + "." + field.Name()
+		c.errPath = errPath
+
+		c.pushPath(pathPart{name: "." + field.Name()})
+
+		fieldName := &ast.SelectorExpr{
 			X:   name,
 			Sel: ast.NewIdent(field.Name()),
-		}, field.Type())
+		}
+
+		if c.versioned(field) {
+			c.readOptional(fieldName, field.Type(), versionCond(field))
+		} else {
+			c.readType(fieldName, field.Type())
+		}
+
+		if _, fixed := c.fixedSize(field.Type()); c.limits.enabled() && !fixed {
+			c.checkReader()
+		}
 	}
+
+	c.config = conf
+	c.path = path
+	c.errPath = errPath
 }
 
 func (c *constructor) readArray(name ast.Expr, t *types.Array) {
+	if c.readBulk(&ast.SliceExpr{X: name}, t.Elem()) {
+		return
+	}
+
 	d := c.subConstructor()
+	n := c.varName("n")
+	d.path += "[]"
 
+	d.pushPath(pathPart{index: n})
 	d.readType(&ast.IndexExpr{
 		X:     name,
-		Index: ast.NewIdent("n"),
+		Index: n,
 	}, t.Elem())
-	c.addStatement(&ast.RangeStmt{
+
+	if composite(t.Elem()) {
+		d.checkReader()
+	}
+
+	c.unchecked = c.unchecked || d.unchecked
+
+	c.addRange(&ast.RangeStmt{
 		For: c.newLine(),
-		Key: ast.NewIdent("n"),
+		Key: n,
 		Tok: token.DEFINE,
 		X:   name,
 		Body: &ast.BlockStmt{
@@ -593,12 +801,59 @@ func (c *constructor) readArray(name ast.Expr, t *types.Array) {
 	})
 }
 
+func (c *constructor) readLength() ast.Expr {
+	method, _ := c.length()
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("r"),
+			Sel: ast.NewIdent("Read" + method),
+		},
+	}
+}
+
 func (c *constructor) readSlice(name ast.Expr, t *types.Slice) {
-	c.makeSlice(name, t)
-	c.readArray(name, types.NewArray(t.Elem(), 0))
+	c.readNilable(name, func(d *constructor) {
+		if d.zeroCopy && isByteSlice(t) {
+			d.aliasBytes(name)
+
+			return
+		}
+
+		d.makeSlice(name, t)
+
+		if !d.readBulk(name, t.Elem()) {
+			d.readArray(name, types.NewArray(t.Elem(), 0))
+		}
+	})
+}
+
+func (c *constructor) readNilable(name ast.Expr, fn func(*constructor)) {
+	if !c.keepNil {
+		fn(c)
+
+		return
+	}
+
+	d := c.subConstructor()
+
+	fn(d)
+
+	c.unchecked = c.unchecked || d.unchecked
+
+	c.readNonNil(name, d.statements)
 }
 
 func (c *constructor) makeSlice(name ast.Expr, t *types.Slice) {
+	_, kind := c.length()
+	length, kind := c.checkLength("slice length", c.limits.slice, sizeof(t.Elem()), c.readLength(), kind)
+
+	if c.reuse {
+		c.reuseSlice(name, length, kind)
+
+		return
+	}
+
 	if typename := c.accessibleIdent(t.Elem()); typename != nil {
 		c.addStatement(&ast.AssignStmt{
 			Lhs: []ast.Expr{name},
@@ -610,12 +865,7 @@ func (c *constructor) makeSlice(name ast.Expr, t *types.Slice) {
 						&ast.ArrayType{
 							Elt: typename,
 						},
-						&ast.CallExpr{
-							Fun: &ast.SelectorExpr{
-								X:   ast.NewIdent("r"),
-								Sel: ast.NewIdent("ReadUintX"),
-							},
-						},
+						length,
 					},
 				},
 			},
@@ -624,22 +874,14 @@ func (c *constructor) makeSlice(name ast.Expr, t *types.Slice) {
 		return
 	}
 
-	c.needSlice = true
+	c.helpers["_make_slice"] = true
 
 	c.addStatement(&ast.ExprStmt{
 		X: &ast.CallExpr{
 			Fun: ast.NewIdent("_make_slice"),
 			Args: []ast.Expr{
-				&ast.UnaryExpr{
-					Op: token.AND,
-					X:  name,
-				},
-				&ast.CallExpr{
-					Fun: &ast.SelectorExpr{
-						X:   ast.NewIdent("r"),
-						Sel: ast.NewIdent("ReadUintX"),
-					},
-				},
+				addr(name),
+				convert(length, types.Typ[kind], types.Uint64),
 			},
 		},
 	})
@@ -651,6 +893,15 @@ func makeSlice() *ast.FuncDecl {
 		Type: &ast.FuncType{
 			TypeParams: &ast.FieldList{
 				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("S")},
+						Type: &ast.UnaryExpr{
+							Op: token.TILDE,
+							X: &ast.ArrayType{
+								Elt: ast.NewIdent("T"),
+							},
+						},
+					},
 					{
 						Names: []*ast.Ident{ast.NewIdent("T")},
 						Type:  ast.NewIdent("any"),
@@ -663,11 +914,13 @@ func makeSlice() *ast.FuncDecl {
 						Names: []*ast.Ident{ast.NewIdent("ptr")},
 						Type: &ast.UnaryExpr{
 							Op: token.MUL,
-							X: &ast.ArrayType{
-								Elt: ast.NewIdent("T"),
-							},
+							X:  ast.NewIdent("S"),
 						},
 					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("l")},
+						Type:  ast.NewIdent("uint64"),
+					},
 				},
 			},
 		},
@@ -685,9 +938,8 @@ func makeSlice() *ast.FuncDecl {
 						&ast.CallExpr{
 							Fun: ast.NewIdent("make"),
 							Args: []ast.Expr{
-								&ast.ArrayType{
-									Elt: ast.NewIdent("T"),
-								},
+								ast.NewIdent("S"),
+								ast.NewIdent("l"),
 							},
 						},
 					},
@@ -698,53 +950,91 @@ func makeSlice() *ast.FuncDecl {
 }
 
 func (c *constructor) readMap(name ast.Expr, t *types.Map) {
+	c.readNilable(name, func(d *constructor) {
+		d.readMapEntries(name, t)
+	})
+}
+
+func (c *constructor) readMapEntries(name ast.Expr, t *types.Map) {
 	d := c.subConstructor()
+	k := c.varName("k")
+	v := c.varName("v")
+	_, kind := c.length()
+	length, _ := c.checkLength("map size", c.limits.mapSize, sizeof(t.Key())+sizeof(t.Elem()), c.readLength(), kind)
+
+	d.addStatement(c.makeMap(name, t, k, v))
+	d.readKeyValue(k, v, t)
+
+	c.unchecked = c.unchecked || d.unchecked
 
-	d.addStatement(c.makeMap(name, t))
-	d.readType(ast.NewIdent("k"), t.Key())
-	d.readType(ast.NewIdent("v"), t.Elem())
 	d.addStatement(&ast.AssignStmt{
 		Lhs: []ast.Expr{
 			&ast.IndexExpr{
 				X:     name,
-				Index: ast.NewIdent("k"),
+				Index: k,
 			},
 		},
 		Tok: token.ASSIGN,
 		Rhs: []ast.Expr{
-			ast.NewIdent("v"),
+			v,
 		},
 	})
 	c.addStatement(&ast.RangeStmt{
-		X: &ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X:   ast.NewIdent("r"),
-				Sel: ast.NewIdent("ReadUintX"),
-			},
-		},
+		X: length,
 		Body: &ast.BlockStmt{
 			List: d.statements,
 		},
 	})
 }
 
-func (c *constructor) makeMap(name ast.Expr, t *types.Map) ast.Stmt {
-	if keytypename, valuetypename := c.accessibleIdent(t.Key()), c.accessibleIdent(t.Elem()); keytypename != nil && valuetypename != nil {
-		c.addStatement(&ast.AssignStmt{
-			Lhs: []ast.Expr{name},
-			Tok: token.ASSIGN,
-			Rhs: []ast.Expr{
-				&ast.CallExpr{
-					Fun: ast.NewIdent("make"),
-					Args: []ast.Expr{
-						&ast.MapType{
-							Key:   keytypename,
-							Value: valuetypename,
+func (c *constructor) readKeyValue(k, v ast.Expr, t *types.Map) {
+	path := c.path
+	c.path = path + "[key]"
+	errPath := c.pushPath(pathPart{name: "[key]"})
+
+	c.readType(k, t.Key())
+
+	if composite(t.Key()) {
+		c.checkReader()
+	}
+
+	c.path = path + "[]"
+	c.errPath = errPath
+
+	c.pushPath(pathPart{key: k})
+	c.readType(v, t.Elem())
+
+	if composite(t.Elem()) {
+		c.checkReader()
+	}
+
+	c.path = path
+	c.errPath = errPath
+}
+
+func (c *constructor) makeMap(name ast.Expr, t *types.Map, k, v *ast.Ident) ast.Stmt {
+	if c.accessible(t.Key()) && c.accessible(t.Elem()) {
+		keytypename, valuetypename := c.accessibleIdent(t.Key()), c.accessibleIdent(t.Elem())
+
+		if c.reuse {
+			c.reuseMap(name)
+		} else {
+			c.addStatement(&ast.AssignStmt{
+				Lhs: []ast.Expr{name},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{
+					&ast.CallExpr{
+						Fun: ast.NewIdent("make"),
+						Args: []ast.Expr{
+							&ast.MapType{
+								Key:   keytypename,
+								Value: valuetypename,
+							},
 						},
 					},
 				},
-			},
-		})
+			})
+		}
 
 		return &ast.DeclStmt{
 			Decl: &ast.GenDecl{
@@ -752,13 +1042,13 @@ func (c *constructor) makeMap(name ast.Expr, t *types.Map) ast.Stmt {
 				Specs: []ast.Spec{
 					&ast.ValueSpec{
 						Names: []*ast.Ident{
-							ast.NewIdent("k"),
+							k,
 						},
 						Type: keytypename,
 					},
 					&ast.ValueSpec{
 						Names: []*ast.Ident{
-							ast.NewIdent("v"),
+							v,
 						},
 						Type: valuetypename,
 					},
@@ -767,29 +1057,32 @@ func (c *constructor) makeMap(name ast.Expr, t *types.Map) ast.Stmt {
 		}
 	}
 
-	c.needMap = true
+	c.helpers["_map_key_value"] = true
 
-	c.addStatement(&ast.ExprStmt{
-		X: &ast.CallExpr{
-			Fun: ast.NewIdent("_make_map"),
-			Args: []ast.Expr{
-				&ast.UnaryExpr{
-					Op: token.AND,
-					X:  name,
+	if c.reuse {
+		c.reuseMap(name)
+	} else {
+		c.helpers["_make_map"] = true
+
+		c.addStatement(&ast.ExprStmt{
+			X: &ast.CallExpr{
+				Fun: ast.NewIdent("_make_map"),
+				Args: []ast.Expr{
+					addr(name),
 				},
 			},
-		},
-	})
+		})
+	}
 
 	return &ast.AssignStmt{
 		Lhs: []ast.Expr{
-			ast.NewIdent("k"),
-			ast.NewIdent("v"),
+			k,
+			v,
 		},
 		Tok: token.DEFINE,
 		Rhs: []ast.Expr{
 			&ast.CallExpr{
-				Fun: ast.NewIdent("_make_key_value"),
+				Fun: ast.NewIdent("_map_key_value"),
 				Args: []ast.Expr{
 					name,
 				},
@@ -798,126 +1091,131 @@ func (c *constructor) makeMap(name ast.Expr, t *types.Map) ast.Stmt {
 	}
 }
 
-func makeMap() []ast.Decl {
-	return []ast.Decl{
-		&ast.FuncDecl{
-			Name: ast.NewIdent("_make_map"),
-			Type: &ast.FuncType{
-				TypeParams: &ast.FieldList{
-					List: []*ast.Field{
-						{
-							Names: []*ast.Ident{ast.NewIdent("K")},
-							Type:  ast.NewIdent("comparable"),
-						},
-						{
-							Names: []*ast.Ident{ast.NewIdent("V")},
-							Type:  ast.NewIdent("any"),
+func makeMap() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_make_map"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("M")},
+						Type: &ast.UnaryExpr{
+							Op: token.TILDE,
+							X: &ast.MapType{
+								Key:   ast.NewIdent("K"),
+								Value: ast.NewIdent("V"),
+							},
 						},
 					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("K")},
+						Type:  ast.NewIdent("comparable"),
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("V")},
+						Type:  ast.NewIdent("any"),
+					},
 				},
-				Params: &ast.FieldList{
-					List: []*ast.Field{
-						{
-							Names: []*ast.Ident{ast.NewIdent("ptr")},
-							Type: &ast.UnaryExpr{
-								Op: token.MUL,
-								X: &ast.MapType{
-									Key:   ast.NewIdent("K"),
-									Value: ast.NewIdent("V"),
-								},
-							},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("ptr")},
+						Type: &ast.UnaryExpr{
+							Op: token.MUL,
+							X:  ast.NewIdent("M"),
 						},
 					},
 				},
 			},
-			Body: &ast.BlockStmt{
-				List: []ast.Stmt{
-					&ast.AssignStmt{
-						Lhs: []ast.Expr{
-							&ast.UnaryExpr{
-								Op: token.MUL,
-								X:  ast.NewIdent("ptr"),
-							},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						&ast.UnaryExpr{
+							Op: token.MUL,
+							X:  ast.NewIdent("ptr"),
 						},
-						Tok: token.ASSIGN,
-						Rhs: []ast.Expr{
-							&ast.CallExpr{
-								Fun: ast.NewIdent("make"),
-								Args: []ast.Expr{
-									&ast.MapType{
-										Key:   ast.NewIdent("K"),
-										Value: ast.NewIdent("V"),
-									},
-								},
+					},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: ast.NewIdent("make"),
+							Args: []ast.Expr{
+								ast.NewIdent("M"),
 							},
 						},
 					},
 				},
 			},
 		},
-		&ast.FuncDecl{
-			Name: ast.NewIdent("_map_key_value"),
-			Type: &ast.FuncType{
-				TypeParams: &ast.FieldList{
-					List: []*ast.Field{
-						{
-							Names: []*ast.Ident{ast.NewIdent("K")},
-							Type:  ast.NewIdent("comparable"),
-						},
-						{
-							Names: []*ast.Ident{ast.NewIdent("V")},
-							Type:  ast.NewIdent("any"),
-						},
+	}
+}
+
+func mapKeyValue() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_map_key_value"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("K")},
+						Type:  ast.NewIdent("comparable"),
+					},
+					{
+						Names: []*ast.Ident{ast.NewIdent("V")},
+						Type:  ast.NewIdent("any"),
 					},
 				},
-				Params: &ast.FieldList{
-					List: []*ast.Field{
-						{
-							Names: []*ast.Ident{ast.NewIdent("_")},
-							Type: &ast.MapType{
-								Key:   ast.NewIdent("K"),
-								Value: ast.NewIdent("V"),
-							},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("_")},
+						Type: &ast.MapType{
+							Key:   ast.NewIdent("K"),
+							Value: ast.NewIdent("V"),
 						},
 					},
 				},
-				Results: &ast.FieldList{
-					List: []*ast.Field{
-						{
-							Type: ast.NewIdent("K"),
-						},
-						{
-							Type: ast.NewIdent("V"),
-						},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("K"),
+					},
+					{
+						Type: ast.NewIdent("V"),
 					},
 				},
 			},
-			Body: &ast.BlockStmt{
-				List: []ast.Stmt{
-					&ast.DeclStmt{
-						Decl: &ast.GenDecl{
-							Tok: token.VAR,
-							Specs: []ast.Spec{
-								&ast.ValueSpec{
-									Names: []*ast.Ident{
-										ast.NewIdent("k"),
-									},
-									Type: ast.NewIdent("K"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{
+									ast.NewIdent("k"),
 								},
-								&ast.ValueSpec{
-									Names: []*ast.Ident{
-										ast.NewIdent("v"),
-									},
-									Type: ast.NewIdent("V"),
+								Type: ast.NewIdent("K"),
+							},
+							&ast.ValueSpec{
+								Names: []*ast.Ident{
+									ast.NewIdent("v"),
 								},
+								Type: ast.NewIdent("V"),
 							},
 						},
 					},
-					&ast.ReturnStmt{
-						Results: []ast.Expr{
-							ast.NewIdent("k"),
-							ast.NewIdent("v"),
-						},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("k"),
+						ast.NewIdent("v"),
 					},
 				},
 			},
@@ -926,10 +1224,23 @@ func makeMap() []ast.Decl {
 }
 
 func (c *constructor) readPointer(name ast.Expr, t *types.Pointer) {
+	if c.refs {
+		c.readRef(name, t)
+
+		return
+	}
+
 	d := c.subConstructor()
 
-	c.new(name, t)
-	d.readType(name, t.Elem())
+	d.new(name, t)
+	d.readType(&ast.StarExpr{X: name}, t.Elem())
+
+	c.unchecked = c.unchecked || d.unchecked
+
+	c.readNonNil(name, d.statements)
+}
+
+func (c *constructor) readNonNil(name ast.Expr, body []ast.Stmt) {
 	c.addStatement(&ast.IfStmt{
 		Cond: &ast.CallExpr{
 			Fun: &ast.SelectorExpr{
@@ -938,7 +1249,18 @@ func (c *constructor) readPointer(name ast.Expr, t *types.Pointer) {
 			},
 		},
 		Body: &ast.BlockStmt{
-			List: d.statements,
+			List: body,
+		},
+		Else: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{name},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						ast.NewIdent("nil"),
+					},
+				},
+			},
 		},
 	})
 }
@@ -957,16 +1279,13 @@ func (c *constructor) new(name ast.Expr, t *types.Pointer) {
 		return
 	}
 
-	c.needPtr = true
+	c.helpers["_new"] = true
 
 	c.addStatement(&ast.ExprStmt{
 		X: &ast.CallExpr{
 			Fun: ast.NewIdent("_new"),
 			Args: []ast.Expr{
-				&ast.UnaryExpr{
-					Op: token.AND,
-					X:  name,
-				},
+				addr(name),
 			},
 		},
 	})
@@ -1023,57 +1342,73 @@ func newFunc() *ast.FuncDecl {
 	}
 }
 
+func (c *constructor) accessible(t types.Type) bool {
+	if _, ok := t.(*types.TypeParam); ok {
+		return true
+	} else if named, ok := t.(*types.Named); ok {
+		if !named.Obj().Exported() && named.Obj().Pkg() != c.pkg && named.Obj().Pkg() != nil {
+			return false
+		}
+
+		for _, arg := range typeArgs(named) {
+			if !c.accessible(arg) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	_, ok := t.Underlying().(*types.Basic)
+
+	return ok
+}
+
 func (c *constructor) accessibleIdent(t types.Type) ast.Expr {
-	if named, ok := t.(*types.Named); ok && (named.Obj().Exported() || named.Obj().Pkg() == c.pkg || named.Obj().Pkg() == nil) {
-		if named.Obj().Pkg() == c.pkg || named.Obj().Pkg() == nil {
-			return ast.NewIdent(named.Obj().Name())
+	if !c.accessible(t) {
+		return nil
+	} else if tp, ok := t.(*types.TypeParam); ok {
+		return ast.NewIdent(tp.Obj().Name())
+	} else if named, ok := t.(*types.Named); ok {
+		var typename ast.Expr = ast.NewIdent(named.Obj().Name())
+
+		if named.Obj().Pkg() != c.pkg && named.Obj().Pkg() != nil {
+			c.usePackage(named.Obj().Pkg())
+
+			typename = &ast.SelectorExpr{
+				X:   ast.NewIdent(named.Obj().Pkg().Name()),
+				Sel: ast.NewIdent(named.Obj().Name()),
+			}
+		}
+
+		args := typeArgs(named)
+		if len(args) == 0 {
+			return typename
+		}
+
+		indices := make([]ast.Expr, len(args))
+
+		for n, arg := range args {
+			indices[n] = c.accessibleIdent(arg)
 		}
 
-		return &ast.SelectorExpr{
-			X:   ast.NewIdent(named.Obj().Pkg().Name()),
-			Sel: ast.NewIdent(named.Obj().Name()),
+		return &ast.IndexListExpr{
+			X:       typename,
+			Indices: indices,
 		}
-	} else if basic, ok := t.Underlying().(*types.Basic); ok {
-		return ast.NewIdent(basic.Name())
 	}
 
-	return nil
+	return ast.NewIdent(t.Underlying().(*types.Basic).Name())
 }
 
-func (c *constructor) readBasic(name ast.Expr, t *types.Basic) {
+func (c *constructor) readBasic(name ast.Expr, typ types.Type, t *types.Basic) {
 	switch t.Kind() {
-	case types.Bool:
-		c.addReader("ReadBool", name)
-	case types.Int:
-		c.addReader("ReadInt64", name)
-	case types.Int8:
-		c.addReader("ReadInt8", name)
-	case types.Int16:
-		c.addReader("ReadInt16", name)
-	case types.Int32:
-		c.addReader("ReadInt32", name)
-	case types.Int64:
-		c.addReader("ReadInt64", name)
-	case types.Uint:
-		c.addReader("ReadUint64", name)
-	case types.Uint8:
-		c.addReader("ReadUint8", name)
-	case types.Uint16:
-		c.addReader("ReadUint16", name)
-	case types.Uint32:
-		c.addReader("ReadUint32", name)
-	case types.Uint64, types.Uintptr:
-		c.addReader("ReadUint64", name)
-	case types.Float32:
-		c.addReader("ReadFloat32", name)
-	case types.Float64:
-		c.addReader("ReadFloat64", name)
 	case types.Complex64:
 		c.addStatement(&ast.AssignStmt{
 			Lhs: []ast.Expr{name},
 			Tok: token.ASSIGN,
 			Rhs: []ast.Expr{
-				&ast.CallExpr{
+				c.convertTo(&ast.CallExpr{
 					Fun: ast.NewIdent("complex"),
 					Args: []ast.Expr{
 						&ast.CallExpr{
@@ -1089,7 +1424,7 @@ func (c *constructor) readBasic(name ast.Expr, t *types.Basic) {
 							},
 						},
 					},
-				},
+				}, typ, types.Complex64),
 			},
 		})
 	case types.Complex128:
@@ -1097,7 +1432,7 @@ func (c *constructor) readBasic(name ast.Expr, t *types.Basic) {
 			Lhs: []ast.Expr{name},
 			Tok: token.ASSIGN,
 			Rhs: []ast.Expr{
-				&ast.CallExpr{
+				c.convertTo(&ast.CallExpr{
 					Fun: ast.NewIdent("complex"),
 					Args: []ast.Expr{
 						&ast.CallExpr{
@@ -1113,73 +1448,301 @@ func (c *constructor) readBasic(name ast.Expr, t *types.Basic) {
 							},
 						},
 					},
-				},
+				}, typ, types.Complex128),
 			},
 		})
 	case types.String:
-		c.addReader("ReadStringX", name)
+		c.readString(name, typ)
+	default:
+		if method, kind := c.basicMethod(t.Kind()); method != "" {
+			c.addReader("Read"+method, name, typ, kind)
+		} else {
+			c.unsupported(typ)
+		}
+	}
+}
+
+func (c *constructor) convertTo(value ast.Expr, typ types.Type, kind types.BasicKind) ast.Expr {
+	if types.Identical(typ, types.Typ[kind]) {
+		return value
+	}
+
+	typename := c.accessibleIdent(typ)
+	if typename == nil {
+		return value
+	}
+
+	return &ast.CallExpr{
+		Fun:  typename,
+		Args: []ast.Expr{value},
 	}
 }
 
 func (c *constructor) unmarshalFunc(typ *types.Named) *ast.FuncDecl {
-	typeName := typ.Obj().Name()
-	unmarshalName := unmarshalName(typ)
-	c.statements = nil
+	c.statements = c.enterDepth()
+	c.path = typ.Obj().Name()
 
-	c.readType(ast.NewIdent("t"), typ)
+	c.readVersion()
 
-	return &ast.FuncDecl{
-		Name: &ast.Ident{
-			Name: unmarshalName,
+	if t, ok := c.taggedStruct(typ); ok {
+		c.readTagged(t)
+	} else if t, ok := c.sparseStruct(typ); ok {
+		c.readSparse(t)
+	} else {
+		c.readUnderlying(&ast.StarExpr{X: ast.NewIdent("t")}, typ)
+	}
+	c.checkReader()
+
+	params, typeParam := c.typeParams(typ, "R")
+
+	return c.streamFunc(unmarshalName(typ), false, params, typeParam, c.accessibleIdent(typ), append(c.statements, c.returnLimits()))
+}
+
+func (c *constructor) strictBody(unmarshalName string) []ast.Stmt {
+	c.use("cmp")
+	c.use("errors")
+	c.use("io")
+
+	var (
+		reader    ast.Expr = ast.NewIdent("br")
+		remaining ast.Expr = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("br"),
+				Sel: ast.NewIdent("Len"),
+			},
+		}
+		buffer ast.Expr = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent("bytes"),
+				Sel: ast.NewIdent("NewReader"),
+			},
+			Args: []ast.Expr{
+				ast.NewIdent("b"),
+			},
+		}
+	)
+
+	if c.zeroCopy {
+		c.helpers["_alias_reader"] = true
+
+		reader = &ast.UnaryExpr{
+			Op: token.AND,
+			X:  reader,
+		}
+		remaining = &ast.CallExpr{
+			Fun: ast.NewIdent("len"),
+			Args: []ast.Expr{
+				ast.NewIdent("br"),
+			},
+		}
+		buffer = &ast.CallExpr{
+			Fun: ast.NewIdent("_alias_reader"),
+			Args: []ast.Expr{
+				ast.NewIdent("b"),
+			},
+		}
+	} else {
+		c.use("bytes")
+	}
+
+	return []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("br"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				buffer,
+			},
 		},
-		Type: &ast.FuncType{
-			Func: c.newLine(),
-			TypeParams: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Names: []*ast.Ident{
-							ast.NewIdent("R"),
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("sr"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CompositeLit{
+					Type: &ast.SelectorExpr{
+						X:   ast.NewIdent("byteio"),
+						Sel: ast.NewIdent("Sticky" + c.endian() + "Reader"),
+					},
+					Elts: []ast.Expr{
+						&ast.KeyValueExpr{
+							Key:   ast.NewIdent("Reader"),
+							Value: reader,
 						},
-						Type: &ast.SelectorExpr{
-							X:   ast.NewIdent("byteio"),
-							Sel: ast.NewIdent("StickyReader"),
+					},
+				},
+			},
+		},
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: &ast.SelectorExpr{
+						X:   ast.NewIdent("cmp"),
+						Sel: ast.NewIdent("Or"),
+					},
+					Args: []ast.Expr{
+						&ast.CallExpr{
+							Fun: ast.NewIdent(unmarshalName),
+							Args: c.newRefsArgs(c.newLimitArgs(
+								ast.NewIdent("t"),
+								&ast.UnaryExpr{
+									Op: token.AND,
+									X:  ast.NewIdent("sr"),
+								},
+							)...),
+						},
+						&ast.SelectorExpr{
+							X:   ast.NewIdent("sr"),
+							Sel: ast.NewIdent("Err"),
 						},
 					},
 				},
 			},
-			Params: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Names: []*ast.Ident{
-							ast.NewIdent("t"),
+		},
+		&ast.IfStmt{
+			If: c.newLine(),
+			Init: &ast.AssignStmt{
+				Lhs: []ast.Expr{
+					ast.NewIdent("err"),
+				},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{
+					c.decodeDone(ast.NewIdent("err"), &ast.SelectorExpr{
+						X:   ast.NewIdent("sr"),
+						Sel: ast.NewIdent("Count"),
+					}),
+				},
+			},
+			Cond: &ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("errors"),
+					Sel: ast.NewIdent("Is"),
+				},
+				Args: []ast.Expr{
+					ast.NewIdent("err"),
+					&ast.SelectorExpr{
+						X:   ast.NewIdent("io"),
+						Sel: ast.NewIdent("EOF"),
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							&ast.SelectorExpr{
+								X:   ast.NewIdent("io"),
+								Sel: ast.NewIdent("ErrUnexpectedEOF"),
+							},
 						},
-						Type: &ast.UnaryExpr{
-							Op: token.MUL,
-							X:  ast.NewIdent(typeName),
+					},
+				},
+			},
+			Else: &ast.IfStmt{
+				Cond: &ast.BinaryExpr{
+					X:  ast.NewIdent("err"),
+					Op: token.NEQ,
+					Y:  ast.NewIdent("nil"),
+				},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.ReturnStmt{
+							Results: []ast.Expr{
+								ast.NewIdent("err"),
+							},
 						},
 					},
-					{
-						Names: []*ast.Ident{
-							ast.NewIdent("r"),
+				},
+				Else: &ast.IfStmt{
+					Cond: &ast.BinaryExpr{
+						X:  remaining,
+						Op: token.GTR,
+						Y: &ast.BasicLit{
+							Kind:  token.INT,
+							Value: "0",
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							c.trailingBytes(remaining),
 						},
-						Type: ast.NewIdent("R"),
 					},
 				},
 			},
-			Results: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Type: ast.NewIdent("error"),
+		},
+		&ast.ReturnStmt{
+			Return: c.newLine(),
+			Results: []ast.Expr{
+				ast.NewIdent("nil"),
+			},
+		},
+	}
+}
+
+func (c *constructor) trailingBytes(n ast.Expr) *ast.ReturnStmt {
+	c.use("fmt")
+
+	c.helpers["ErrTrailingBytes"] = true
+
+	return &ast.ReturnStmt{
+		Results: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X:   ast.NewIdent("fmt"),
+					Sel: ast.NewIdent("Errorf"),
+				},
+				Args: []ast.Expr{
+					&ast.BasicLit{
+						Kind:  token.STRING,
+						Value: `"%w: %d"`,
 					},
+					ast.NewIdent("ErrTrailingBytes"),
+					n,
 				},
 			},
 		},
-		Body: &ast.BlockStmt{
-			List: append(c.statements, &ast.ReturnStmt{
-				Results: []ast.Expr{
-					ast.NewIdent("nil"),
+	}
+}
+
+func (c *constructor) errorVar(name, doc, msg string) *ast.GenDecl {
+	c.use("errors")
+
+	return &ast.GenDecl{
+		Doc: &ast.CommentGroup{
+			List: []*ast.Comment{
+				{
+					Slash: c.newLine(),
+					Text:  "// " + name + " " + doc,
 				},
-			}),
+			},
+		},
+		Tok: token.VAR,
+		Specs: []ast.Spec{
+			&ast.ValueSpec{
+				Names: []*ast.Ident{
+					ast.NewIdent(name),
+				},
+				Values: []ast.Expr{
+					&ast.CallExpr{
+						Fun: &ast.SelectorExpr{
+							X:   ast.NewIdent("errors"),
+							Sel: ast.NewIdent("New"),
+						},
+						Args: []ast.Expr{
+							&ast.BasicLit{
+								Kind:  token.STRING,
+								Value: strconv.Quote(msg),
+							},
+						},
+					},
+				},
+			},
 		},
 	}
 }
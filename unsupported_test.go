@@ -0,0 +1,36 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnsupported(t *testing.T) {
+	for n, test := range [...]struct {
+		args []string
+		err  string
+	}{
+		{[]string{"Chan"}, "unsupported type: chan int (chan) at Chan.C"},
+		{[]string{"Func"}, "unsupported type: func() (func) at Func.Inner.F"},
+		{[]string{"Slice"}, "unsupported type: unsafe.Pointer (unsafe.Pointer) at Slice.Items[].P"},
+		{[]string{"Map"}, "unsupported type: any (interface) at Map.M[]"},
+		{[]string{"Tagged"}, ""},
+		{[]string{"-skipunsupported", "Chan", "Func", "Slice", "Map"}, ""},
+	} {
+		dir := t.TempDir()
+
+		copyFixture(t, filepath.Join("testdata", "unsupported"), dir)
+
+		out, err := generate(dir, append([]string{"-o", "marshal.go"}, test.args...)...)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %s\n%s", n+1, err, out)
+			}
+		} else if err == nil {
+			t.Errorf("test %d: expecting error, got none", n+1)
+		} else if !strings.Contains(string(out), test.err) {
+			t.Errorf("test %d: expecting error %q, got %q", n+1, test.err, out)
+		}
+	}
+}
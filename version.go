@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+func (c *constructor) versioned(f structField) bool {
+	if f.since == 0 && f.removed == 0 {
+		return false
+	}
+
+	if c.version == 0 {
+		c.setError(fmt.Errorf("%w: %s: field versions require a version directive", ErrInvalidTag, c.path))
+	} else if max(f.since, f.removed) > c.version {
+		c.setError(fmt.Errorf("%w: %s: field version is greater than the type version %d", ErrInvalidTag, c.path, c.version))
+	}
+
+	return true
+}
+
+func versionCond(f structField) ast.Expr {
+	var cond ast.Expr
+
+	if f.since > 1 {
+		cond = &ast.BinaryExpr{
+			X:  ast.NewIdent("ver"),
+			Op: token.GEQ,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: strconv.FormatUint(f.since, 10),
+			},
+		}
+	}
+
+	if f.removed > 0 {
+		cond = andCond(cond, &ast.BinaryExpr{
+			X:  ast.NewIdent("ver"),
+			Op: token.LSS,
+			Y: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: strconv.FormatUint(f.removed, 10),
+			},
+		})
+	}
+
+	return cond
+}
+
+func andCond(a, b ast.Expr) ast.Expr {
+	if a == nil {
+		return b
+	} else if b == nil {
+		return a
+	}
+
+	return &ast.BinaryExpr{
+		X:  a,
+		Op: token.LAND,
+		Y:  b,
+	}
+}
+
+func (c *constructor) readOptional(name ast.Expr, typ types.Type, cond ast.Expr) {
+	if cond == nil {
+		c.readType(name, typ)
+
+		return
+	}
+
+	d := c.subConstructor()
+
+	d.readType(name, typ)
+	d.checkReader()
+
+	c.helpers["_zero"] = true
+
+	c.addStatement(&ast.IfStmt{
+		Cond: cond,
+		Body: &ast.BlockStmt{
+			List: d.statements,
+		},
+		Else: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ExprStmt{
+					X: &ast.CallExpr{
+						Fun: ast.NewIdent("_zero"),
+						Args: []ast.Expr{
+							addr(name),
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func (c *constructor) writeVersion() {
+	if c.version == 0 {
+		return
+	}
+
+	c.addWriter("WriteUintX", &ast.BasicLit{
+		Kind:  token.INT,
+		Value: strconv.FormatUint(c.version, 10),
+	})
+}
+
+func (c *constructor) readVersion() {
+	if c.version == 0 {
+		return
+	}
+
+	c.use("fmt")
+
+	c.helpers["DecodeError"] = true
+	c.helpers["ErrUnsupportedVersion"] = true
+
+	c.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{
+			ast.NewIdent("ver"),
+		},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			readCall("ReadUintX"),
+		},
+	})
+	c.addStatement(&ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun: ast.NewIdent("_read_error"),
+					Args: []ast.Expr{
+						ast.NewIdent("r"),
+					},
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						c.decodeError(ast.NewIdent("err")),
+					},
+				},
+			},
+		},
+		Else: &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X:  ast.NewIdent("ver"),
+					Op: token.EQL,
+					Y: &ast.BasicLit{
+						Kind:  token.INT,
+						Value: "0",
+					},
+				},
+				Op: token.LOR,
+				Y: &ast.BinaryExpr{
+					X:  ast.NewIdent("ver"),
+					Op: token.GTR,
+					Y: &ast.BasicLit{
+						Kind:  token.INT,
+						Value: strconv.FormatUint(c.version, 10),
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							c.decodeError(&ast.CallExpr{
+								Fun: &ast.SelectorExpr{
+									X:   ast.NewIdent("fmt"),
+									Sel: ast.NewIdent("Errorf"),
+								},
+								Args: []ast.Expr{
+									&ast.BasicLit{
+										Kind:  token.STRING,
+										Value: `"%w: %d"`,
+									},
+									ast.NewIdent("ErrUnsupportedVersion"),
+									ast.NewIdent("ver"),
+								},
+							}),
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func zeroFunc() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_zero"),
+		Type: &ast.FuncType{
+			TypeParams: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("T")},
+						Type:  ast.NewIdent("any"),
+					},
+				},
+			},
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{ast.NewIdent("ptr")},
+						Type: &ast.UnaryExpr{
+							Op: token.MUL,
+							X:  ast.NewIdent("T"),
+						},
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.DeclStmt{
+					Decl: &ast.GenDecl{
+						Tok: token.VAR,
+						Specs: []ast.Spec{
+							&ast.ValueSpec{
+								Names: []*ast.Ident{ast.NewIdent("zero")},
+								Type:  ast.NewIdent("T"),
+							},
+						},
+					},
+				},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						&ast.UnaryExpr{
+							Op: token.MUL,
+							X:  ast.NewIdent("ptr"),
+						},
+					},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						ast.NewIdent("zero"),
+					},
+				},
+			},
+		},
+	}
+}
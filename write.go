@@ -20,17 +20,89 @@ func (p *pos) newLine() token.Pos {
 	return token.Pos(l + 1)
 }
 
+type config struct {
+	bigEndian  bool
+	varint     bool
+	lenWidth   int
+	sortKeys   bool
+	skip       bool
+	strict     bool
+	version    uint64
+	tagged     bool
+	omitZero   bool
+	keepNil    bool
+	refs       bool
+	zeroCopy   bool
+	unexported bool
+	reuse      bool
+	limits     limits
+}
+
+func (c config) length() (string, types.BasicKind) {
+	switch c.lenWidth {
+	case 8:
+		return "Uint8", types.Uint8
+	case 16:
+		return "Uint16", types.Uint16
+	case 32:
+		return "Uint32", types.Uint32
+	case 64:
+		return "Uint64", types.Uint64
+	}
+
+	return "UintX", types.Uint64
+}
+
+func (c config) stringMethod() string {
+	if c.lenWidth == 0 {
+		return "StringX"
+	}
+
+	return "String" + strconv.Itoa(c.lenWidth)
+}
+
+func (c config) endian() string {
+	if c.bigEndian {
+		return "BigEndian"
+	}
+
+	return "LittleEndian"
+}
+
+func (c config) endianComment() string {
+	if c.bigEndian {
+		return "big-endian"
+	}
+
+	return "little-endian"
+}
+
 type constructor struct {
 	pkg *types.Package
 	pos
-	types                       map[*types.Named][2]string
-	statements                  []ast.Stmt
-	needPtr, needSlice, needMap bool
+	config
+	types      map[*types.Named][2]string
+	configs    map[*types.Named]config
+	packages   map[string]string
+	helpers    map[string]bool
+	inlining   map[*types.Named]bool
+	queue      *[]*types.Named
+	defaults   config
+	dirs       directives
+	path       string
+	errPath    []pathPart
+	unchecked  bool
+	sizing     bool
+	depth      int
+	err        *error
+	statements []ast.Stmt
 }
 
-func constructFile(w io.Writer, pkgName string, assigner, marshaler, unmarshaler, writer, reader string, opts []string, pkg *types.Package, typenames ...string) error {
+func constructFile(w io.Writer, pkgName string, assigner, marshaler, unmarshaler, writer, reader, sizer string, conf config, dirs directives, opts []string, pkg *types.Package, typenames ...string) error {
 	var typs []*types.Named
 
+	configs := make(map[*types.Named]config)
+
 	for _, typename := range typenames {
 		typ := pkg.Scope().Lookup(typename)
 		if typ == nil {
@@ -42,17 +114,30 @@ func constructFile(w io.Writer, pkgName string, assigner, marshaler, unmarshaler
 			return fmt.Errorf("%w: %s", ErrNotAType, typename)
 		}
 
-		if named.TypeArgs().Len() != 0 {
-			return fmt.Errorf("%w: %s", ErrGenericType, typename)
+		typeConf, err := conf.apply(typename, dirs[typename])
+		if err != nil {
+			return err
 		}
 
 		typs = append(typs, named)
+		configs[named] = typeConf
 	}
 
+	var err error
+
 	c := constructor{
-		pkg:   pkg,
-		pos:   pos{0},
-		types: make(map[*types.Named][2]string),
+		pkg:      pkg,
+		pos:      pos{0},
+		config:   conf,
+		types:    make(map[*types.Named][2]string),
+		configs:  configs,
+		packages: make(map[string]string),
+		helpers:  make(map[string]bool),
+		inlining: make(map[*types.Named]bool),
+		queue:    &typs,
+		defaults: conf,
+		dirs:     dirs,
+		err:      &err,
 	}
 	file := &ast.File{
 		Doc: &ast.CommentGroup{
@@ -65,8 +150,13 @@ func constructFile(w io.Writer, pkgName string, assigner, marshaler, unmarshaler
 		},
 		Name:    ast.NewIdent(pkgName),
 		Package: c.newLine(),
-		Decls:   c.buildDecls(assigner, marshaler, unmarshaler, writer, reader, typs),
+		Decls:   c.buildDecls(assigner, marshaler, unmarshaler, writer, reader, sizer, typs),
+	}
+
+	if err != nil {
+		return err
 	}
+
 	fset := token.NewFileSet()
 	wsfile := fset.AddFile("out.go", 1, len(c.pos))
 
@@ -75,6 +165,293 @@ func constructFile(w io.Writer, pkgName string, assigner, marshaler, unmarshaler
 	return format.Node(w, fset, file)
 }
 
+func (c *constructor) callable(typ *types.Named) ([2]string, bool) {
+	if names, ok := c.types[typ.Origin()]; ok && (typ.TypeArgs().Len() == 0 || hasTypeParams(typ)) {
+		return names, true
+	}
+
+	for t, names := range c.types {
+		if types.Identical(t, typ) {
+			return names, true
+		}
+	}
+
+	conf, known := c.configs[typ.Origin()]
+	if !known {
+		conf, known = c.ownConfig(typ)
+	}
+
+	if !c.inlining[typ.Origin()] && !known {
+		return [2]string{}, false
+	} else if hasTypeParams(typ) {
+		typ = typ.Origin()
+	}
+
+	if !c.accessible(typ) {
+		c.setError(fmt.Errorf("%w: %s", ErrRecursiveType, typ))
+
+		return [2]string{}, false
+	} else if !known {
+		var err error
+
+		if conf, err = c.defaults.apply(typ.Obj().Name(), c.dirs[typ.Obj().Name()]); err != nil {
+			c.setError(err)
+		}
+	}
+
+	names := [2]string{marshalName(typ), unmarshalName(typ)}
+	c.types[typ] = names
+	c.configs[typ] = conf
+	*c.queue = append(*c.queue, typ)
+
+	return names, true
+}
+
+func (c *constructor) ownConfig(typ *types.Named) (config, bool) {
+	if _, ok := typ.Underlying().(*types.Struct); !ok || !c.accessible(typ) {
+		return config{}, false
+	}
+
+	var dirs []string
+
+	if typ.Obj().Pkg() == c.pkg {
+		dirs = c.dirs[typ.Obj().Name()]
+	}
+
+	conf, err := c.defaults.apply(typ.Obj().Name(), dirs)
+	if err != nil {
+		c.setError(err)
+
+		return config{}, false
+	}
+
+	return conf, len(dirs) > 0 || conf.tagged || conf.omitZero
+}
+
+func (c *constructor) streamFunc(name string, marshal bool, typeParams []*ast.Field, typeParam string, typ ast.Expr, body []ast.Stmt) *ast.FuncDecl {
+	constraint, stream := "StickyReader", "r"
+
+	if marshal {
+		constraint, stream = "StickyWriter", "w"
+	}
+
+	params := []*ast.Field{
+		{
+			Names: []*ast.Ident{
+				ast.NewIdent("t"),
+			},
+			Type: &ast.UnaryExpr{
+				Op: token.MUL,
+				X:  typ,
+			},
+		},
+		{
+			Names: []*ast.Ident{
+				ast.NewIdent(stream),
+			},
+			Type: ast.NewIdent(typeParam),
+		},
+	}
+
+	if !marshal {
+		params = c.limitParam(params)
+	}
+
+	params = c.refsParam(params)
+
+	return &ast.FuncDecl{
+		Name: &ast.Ident{
+			Name: name,
+		},
+		Type: &ast.FuncType{
+			Func: c.newLine(),
+			TypeParams: &ast.FieldList{
+				List: append(typeParams, &ast.Field{
+					Names: []*ast.Ident{
+						ast.NewIdent(typeParam),
+					},
+					Type: &ast.SelectorExpr{
+						X:   ast.NewIdent("byteio"),
+						Sel: ast.NewIdent(constraint),
+					},
+				}),
+			},
+			Params: &ast.FieldList{
+				List: params,
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: ast.NewIdent("error"),
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: body,
+		},
+	}
+}
+
+func (c *constructor) callFunc(funcName, stream string, name ast.Expr) {
+	if c.sizing {
+		c.addSize(&ast.CallExpr{
+			Fun:  ast.NewIdent(sizeName(funcName)),
+			Args: c.refsArgs(addr(name)),
+		})
+
+		return
+	}
+
+	args := []ast.Expr{
+		addr(name),
+		ast.NewIdent(stream),
+	}
+
+	var err ast.Expr = ast.NewIdent("err")
+
+	if stream == "r" {
+		args = c.limitArgs(args...)
+		err = c.decodeError(err)
+	}
+
+	args = c.refsArgs(args...)
+
+	c.addStatement(&ast.IfStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{
+				ast.NewIdent("err"),
+			},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{
+				&ast.CallExpr{
+					Fun:  ast.NewIdent(funcName),
+					Args: args,
+				},
+			},
+		},
+		Cond: &ast.BinaryExpr{
+			X:  ast.NewIdent("err"),
+			Op: token.NEQ,
+			Y:  ast.NewIdent("nil"),
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						err,
+					},
+				},
+			},
+		},
+	})
+}
+
+func addr(name ast.Expr) ast.Expr {
+	if star, ok := name.(*ast.StarExpr); ok {
+		return star.X
+	}
+
+	return &ast.UnaryExpr{
+		Op: token.AND,
+		X:  name,
+	}
+}
+
+func (c *constructor) varName(name string) *ast.Ident {
+	if c.depth == 0 {
+		return ast.NewIdent(name)
+	}
+
+	return ast.NewIdent(name + strconv.Itoa(c.depth))
+}
+
+func (c *constructor) unsupported(typ types.Type) {
+	if !c.skip {
+		c.setError(fmt.Errorf("%w: %s (%s) at %s", ErrUnsupportedType, typ, kindName(typ), c.path))
+	}
+}
+
+func (c *constructor) supported(typ types.Type, seen map[*types.Named]bool) bool {
+	if _, ok := typ.(*types.TypeParam); ok {
+		return true
+	} else if named, ok := typ.(*types.Named); ok {
+		if seen[named] || stdlibFunc(named) != "" || binaryFunc(named, true) != "" {
+			return true
+		} else if seen == nil {
+			seen = make(map[*types.Named]bool)
+		}
+
+		seen[named] = true
+	}
+
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		if c.skip {
+			return true
+		}
+
+		fields, _ := c.structFields(t)
+
+		for _, field := range fields {
+			if !c.supported(field.Type(), seen) {
+				return false
+			}
+		}
+
+		return true
+	case *types.Array:
+		return c.supported(t.Elem(), seen)
+	case *types.Slice:
+		return c.supported(t.Elem(), seen)
+	case *types.Map:
+		return c.supported(t.Key(), seen) && c.supported(t.Elem(), seen)
+	case *types.Pointer:
+		return c.supported(t.Elem(), seen)
+	case *types.Interface:
+		members := c.unionMembers(typ, t)
+
+		for _, member := range members {
+			if !c.supported(member, seen) {
+				return false
+			}
+		}
+
+		return len(members) > 0
+	case *types.Basic:
+		method, _ := c.basicMethod(t.Kind())
+
+		return method != "" || t.Info()&types.IsComplex != 0
+	}
+
+	return false
+}
+
+func kindName(typ types.Type) string {
+	switch t := typ.Underlying().(type) {
+	case *types.Chan:
+		return "chan"
+	case *types.Signature:
+		return "func"
+	case *types.Interface:
+		return "interface"
+	case *types.Basic:
+		if t.Kind() == types.UnsafePointer {
+			return "unsafe.Pointer"
+		}
+
+		return t.Name()
+	}
+
+	return fmt.Sprintf("%T", typ.Underlying())
+}
+
+func (c *constructor) setError(err error) {
+	if *c.err == nil {
+		*c.err = err
+	}
+}
+
 func encodeOpts(opts []string) string {
 	var buf []byte
 
@@ -93,59 +470,108 @@ func encodeOpts(opts []string) string {
 	return string(buf)
 }
 
-func (c *constructor) buildDecls(assigner, marshaler, unmarshaler, writer, reader string, types []*types.Named) []ast.Decl {
-	decls := []ast.Decl{
-		c.imports(writer != "" || reader != ""),
+func (c *constructor) buildDecls(assigner, marshaler, unmarshaler, writer, reader, sizer string, types []*types.Named) []ast.Decl {
+	imports := c.imports()
+	decls := []ast.Decl{imports}
+
+	if writer != "" || reader != "" {
+		c.use("cmp")
+		c.use("io")
 	}
 
 	for _, typ := range types {
-		typeName := typ.Obj().Name()
+		c.types[typ] = [2]string{marshalName(typ), unmarshalName(typ)}
+	}
+
+	for _, typ := range types {
+		typeName := c.accessibleIdent(typ)
 		marshalName := marshalName(typ)
 		unmarshalName := unmarshalName(typ)
-		c.types[typ] = [2]string{marshalName, unmarshalName}
+		c.config = c.configs[typ]
+		size, fixed := c.fixedSize(typ)
+		fixed = fixed && size > 0
 
-		if assigner != "" {
+		if fixed {
+			decls = append(decls, c.sizeConst(typ, size))
+		}
+
+		if assigner != "" && fixed {
+			decls = append(decls, c.assignFixed(typ, typeName, assigner))
+		} else if assigner != "" {
 			decls = append(decls, c.assignBinary(typeName, assigner, marshalName))
 		}
 
 		if marshaler != "" {
-			decls = append(decls, c.marshalBinary(typeName, marshaler, marshalName))
+			decls = append(decls, c.marshalBinary(typeName, marshaler, marshalName, sizer))
 		}
 
 		if writer != "" {
 			decls = append(decls, c.writeTo(typeName, writer, marshalName))
 		}
 
-		if unmarshaler != "" {
+		if unmarshaler != "" && fixed {
+			decls = append(decls, c.unmarshalFixed(typ, typeName, unmarshaler))
+		} else if unmarshaler != "" {
 			decls = append(decls, c.unmarshalBinary(typeName, unmarshaler, unmarshalName))
 		}
 
 		if reader != "" {
 			decls = append(decls, c.readFrom(typeName, reader, unmarshalName))
 		}
+
+		if sizer != "" && fixed {
+			decls = append(decls, c.sizeFixed(typ, typeName, sizer))
+		} else if sizer != "" {
+			decls = append(decls, c.binarySize(typeName, sizer, c.sizeCall(typ)))
+		}
 	}
 
-	for _, typ := range types {
-		if assigner != "" || marshaler != "" || writer != "" {
+	for n := 0; n < len(*c.queue); n++ {
+		typ := (*c.queue)[n]
+		c.config = c.configs[typ]
+
+		if assigner != "" || marshaler != "" || writer != "" || sizer != "" {
 			decls = append(decls, c.marshalFunc(typ))
 		}
 
 		if unmarshaler != "" || reader != "" {
 			decls = append(decls, c.unmarshalFunc(typ))
 		}
+
+		if _, fixed := c.fixedSize(typ); sizer != "" && !fixed {
+			decls = append(decls, c.sizeFunc(typ))
+		}
 	}
 
-	if c.needPtr {
+	if c.helpers["_new"] {
 		decls = append(decls, newFunc())
 	}
 
-	if c.needSlice {
+	if c.helpers["_make_slice"] {
 		decls = append(decls, makeSlice())
 	}
 
-	if c.needMap {
-		decls = append(decls, makeMap()...)
+	if c.helpers["_make_map"] {
+		decls = append(decls, makeMap())
+	}
+
+	if c.helpers["_map_key_value"] {
+		decls = append(decls, mapKeyValue())
 	}
 
+	if c.helpers["_compare_bool"] {
+		decls = append(decls, compareBool())
+	}
+
+	decls = append(decls, c.helperDecls()...)
+	decls = append(decls, c.sizeDecls()...)
+	decls = append(decls, c.aliasDecls()...)
+	decls = append(decls, c.bulkDecls()...)
+	decls = append(decls, c.reuseDecls()...)
+	decls = append(decls, c.limitDecls()...)
+	decls = append(decls, c.refsDecls()...)
+
+	imports.Specs = append(c.importSpecs(), imports.Specs...)
+
 	return decls
 }
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+func isByteSlice(t *types.Slice) bool {
+	return types.Identical(t.Elem(), types.Typ[types.Uint8])
+}
+
+func (c *constructor) aliasCall(fn string, length ast.Expr, kind types.BasicKind) ast.Expr {
+	c.helpers[fn] = true
+
+	return &ast.CallExpr{
+		Fun: ast.NewIdent(fn),
+		Args: []ast.Expr{
+			ast.NewIdent("r"),
+			convert(length, types.Typ[kind], types.Uint64),
+		},
+	}
+}
+
+func (c *constructor) aliasBytes(name ast.Expr) {
+	_, kind := c.length()
+	length, kind := c.checkLength("slice length", c.limits.slice, 1, c.readLength(), kind)
+
+	c.addStatement(&ast.AssignStmt{
+		Lhs: []ast.Expr{name},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{
+			c.aliasCall("_alias_bytes", length, kind),
+		},
+	})
+}
+
+func (c *constructor) aliasDecls() []ast.Decl {
+	var decls []ast.Decl
+
+	if c.helpers["_alias_string"] {
+		c.use("unsafe")
+
+		decls = append(decls, aliasString())
+	}
+
+	if c.helpers["_alias_string"] || c.helpers["_alias_bytes"] {
+		decls = append(decls, aliasBytes(), aliasFunc())
+	} else if !c.helpers["_alias_reader"] {
+		return decls
+	}
+
+	c.use("io")
+
+	return append(decls, aliasReader()...)
+}
+
+func aliasReader() []ast.Decl {
+	buf := &ast.StarExpr{
+		X: ast.NewIdent("a"),
+	}
+
+	return []ast.Decl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{
+				&ast.TypeSpec{
+					Name: ast.NewIdent("_alias_reader"),
+					Type: &ast.ArrayType{
+						Elt: ast.NewIdent("byte"),
+					},
+				},
+			},
+		},
+		&ast.FuncDecl{
+			Recv: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("a"),
+						},
+						Type: &ast.StarExpr{
+							X: ast.NewIdent("_alias_reader"),
+						},
+					},
+				},
+			},
+			Name: ast.NewIdent("Read"),
+			Type: &ast.FuncType{
+				Params: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Names: []*ast.Ident{
+								ast.NewIdent("p"),
+							},
+							Type: &ast.ArrayType{
+								Elt: ast.NewIdent("byte"),
+							},
+						},
+					},
+				},
+				Results: &ast.FieldList{
+					List: []*ast.Field{
+						{
+							Type: ast.NewIdent("int"),
+						},
+						{
+							Type: ast.NewIdent("error"),
+						},
+					},
+				},
+			},
+			Body: &ast.BlockStmt{
+				List: []ast.Stmt{
+					&ast.IfStmt{
+						Cond: &ast.BinaryExpr{
+							X: &ast.CallExpr{
+								Fun:  ast.NewIdent("len"),
+								Args: []ast.Expr{buf},
+							},
+							Op: token.EQL,
+							Y: &ast.BasicLit{
+								Kind:  token.INT,
+								Value: "0",
+							},
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.ReturnStmt{
+									Results: []ast.Expr{
+										&ast.BasicLit{
+											Kind:  token.INT,
+											Value: "0",
+										},
+										&ast.SelectorExpr{
+											X:   ast.NewIdent("io"),
+											Sel: ast.NewIdent("EOF"),
+										},
+									},
+								},
+							},
+						},
+					},
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("n"),
+						},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.CallExpr{
+								Fun: ast.NewIdent("copy"),
+								Args: []ast.Expr{
+									ast.NewIdent("p"),
+									buf,
+								},
+							},
+						},
+					},
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{buf},
+						Tok: token.ASSIGN,
+						Rhs: []ast.Expr{
+							&ast.SliceExpr{
+								X: &ast.ParenExpr{
+									X: buf,
+								},
+								Low: ast.NewIdent("n"),
+							},
+						},
+					},
+					&ast.ReturnStmt{
+						Results: []ast.Expr{
+							ast.NewIdent("n"),
+							ast.NewIdent("nil"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func aliasFuncType(results ...ast.Expr) *ast.FuncType {
+	fields := make([]*ast.Field, len(results))
+
+	for n, result := range results {
+		fields[n] = &ast.Field{
+			Type: result,
+		}
+	}
+
+	return &ast.FuncType{
+		TypeParams: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent("R"),
+					},
+					Type: &ast.SelectorExpr{
+						X:   ast.NewIdent("byteio"),
+						Sel: ast.NewIdent("StickyReader"),
+					},
+				},
+			},
+		},
+		Params: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent("r"),
+					},
+					Type: ast.NewIdent("R"),
+				},
+				{
+					Names: []*ast.Ident{
+						ast.NewIdent("n"),
+					},
+					Type: ast.NewIdent("uint64"),
+				},
+			},
+		},
+		Results: &ast.FieldList{
+			List: fields,
+		},
+	}
+}
+
+func aliasString() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_alias_string"),
+		Type: aliasFuncType(ast.NewIdent("string")),
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: ast.NewIdent("_alias_bytes"),
+							Args: []ast.Expr{
+								ast.NewIdent("r"),
+								ast.NewIdent("n"),
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent("unsafe"),
+								Sel: ast.NewIdent("String"),
+							},
+							Args: []ast.Expr{
+								&ast.CallExpr{
+									Fun: &ast.SelectorExpr{
+										X:   ast.NewIdent("unsafe"),
+										Sel: ast.NewIdent("SliceData"),
+									},
+									Args: []ast.Expr{
+										ast.NewIdent("b"),
+									},
+								},
+								&ast.CallExpr{
+									Fun: ast.NewIdent("len"),
+									Args: []ast.Expr{
+										ast.NewIdent("b"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func aliasMemCase(typ string) *ast.CaseClause {
+	return &ast.CaseClause{
+		List: []ast.Expr{
+			&ast.StarExpr{
+				X: &ast.SelectorExpr{
+					X:   ast.NewIdent("byteio"),
+					Sel: ast.NewIdent(typ),
+				},
+			},
+		},
+		Body: []ast.Stmt{
+			&ast.ReturnStmt{
+				Results: []ast.Expr{
+					&ast.CallExpr{
+						Fun: ast.NewIdent("_alias"),
+						Args: []ast.Expr{
+							&ast.CallExpr{
+								Fun: &ast.ParenExpr{
+									X: &ast.StarExpr{
+										X: &ast.ArrayType{
+											Elt: ast.NewIdent("byte"),
+										},
+									},
+								},
+								Args: []ast.Expr{
+									ast.NewIdent("r"),
+								},
+							},
+							ast.NewIdent("n"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func aliasStickyCase(typ string) *ast.CaseClause {
+	field := func(name string) ast.Expr {
+		return &ast.SelectorExpr{
+			X:   ast.NewIdent("r"),
+			Sel: ast.NewIdent(name),
+		}
+	}
+
+	return &ast.CaseClause{
+		List: []ast.Expr{
+			&ast.StarExpr{
+				X: &ast.SelectorExpr{
+					X:   ast.NewIdent("byteio"),
+					Sel: ast.NewIdent(typ),
+				},
+			},
+		},
+		Body: []ast.Stmt{
+			&ast.IfStmt{
+				Init: &ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("a"),
+						ast.NewIdent("ok"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.TypeAssertExpr{
+							X: field("Reader"),
+							Type: &ast.StarExpr{
+								X: ast.NewIdent("_alias_reader"),
+							},
+						},
+					},
+				},
+				Cond: &ast.BinaryExpr{
+					X:  ast.NewIdent("ok"),
+					Op: token.LAND,
+					Y: &ast.BinaryExpr{
+						X:  field("Err"),
+						Op: token.EQL,
+						Y:  ast.NewIdent("nil"),
+					},
+				},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.AssignStmt{
+							Lhs: []ast.Expr{
+								ast.NewIdent("b"),
+							},
+							Tok: token.DEFINE,
+							Rhs: []ast.Expr{
+								&ast.CallExpr{
+									Fun: ast.NewIdent("_alias"),
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun: &ast.ParenExpr{
+												X: &ast.StarExpr{
+													X: &ast.ArrayType{
+														Elt: ast.NewIdent("byte"),
+													},
+												},
+											},
+											Args: []ast.Expr{
+												ast.NewIdent("a"),
+											},
+										},
+										ast.NewIdent("n"),
+									},
+								},
+							},
+						},
+						&ast.AssignStmt{
+							Lhs: []ast.Expr{
+								field("Count"),
+							},
+							Tok: token.ADD_ASSIGN,
+							Rhs: []ast.Expr{
+								&ast.CallExpr{
+									Fun: ast.NewIdent("int64"),
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun: ast.NewIdent("len"),
+											Args: []ast.Expr{
+												ast.NewIdent("b"),
+											},
+										},
+									},
+								},
+							},
+						},
+						&ast.IfStmt{
+							Cond: &ast.BinaryExpr{
+								X: &ast.CallExpr{
+									Fun: ast.NewIdent("uint64"),
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun: ast.NewIdent("len"),
+											Args: []ast.Expr{
+												ast.NewIdent("b"),
+											},
+										},
+									},
+								},
+								Op: token.LSS,
+								Y:  ast.NewIdent("n"),
+							},
+							Body: &ast.BlockStmt{
+								List: []ast.Stmt{
+									&ast.AssignStmt{
+										Lhs: []ast.Expr{
+											field("Err"),
+										},
+										Tok: token.ASSIGN,
+										Rhs: []ast.Expr{
+											&ast.SelectorExpr{
+												X:   ast.NewIdent("io"),
+												Sel: ast.NewIdent("ErrUnexpectedEOF"),
+											},
+										},
+									},
+								},
+							},
+						},
+						&ast.ReturnStmt{
+							Results: []ast.Expr{
+								ast.NewIdent("b"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func aliasBytes() *ast.FuncDecl {
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_alias_bytes"),
+		Type: aliasFuncType(&ast.ArrayType{
+			Elt: ast.NewIdent("byte"),
+		}),
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.TypeSwitchStmt{
+					Assign: &ast.AssignStmt{
+						Lhs: []ast.Expr{
+							ast.NewIdent("r"),
+						},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{
+							&ast.TypeAssertExpr{
+								X: &ast.CallExpr{
+									Fun: ast.NewIdent("any"),
+									Args: []ast.Expr{
+										ast.NewIdent("r"),
+									},
+								},
+							},
+						},
+					},
+					Body: &ast.BlockStmt{
+						List: []ast.Stmt{
+							aliasMemCase("MemLittleEndian"),
+							aliasMemCase("MemBigEndian"),
+							aliasStickyCase("StickyLittleEndianReader"),
+							aliasStickyCase("StickyBigEndianReader"),
+						},
+					},
+				},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: ast.NewIdent("make"),
+							Args: []ast.Expr{
+								&ast.ArrayType{
+									Elt: ast.NewIdent("byte"),
+								},
+								ast.NewIdent("n"),
+							},
+						},
+					},
+				},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("_"),
+						ast.NewIdent("_"),
+					},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: &ast.SelectorExpr{
+								X:   ast.NewIdent("io"),
+								Sel: ast.NewIdent("ReadFull"),
+							},
+							Args: []ast.Expr{
+								ast.NewIdent("r"),
+								ast.NewIdent("b"),
+							},
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func aliasFunc() *ast.FuncDecl {
+	buf := &ast.StarExpr{
+		X: ast.NewIdent("buf"),
+	}
+
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("_alias"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("buf"),
+						},
+						Type: &ast.StarExpr{
+							X: &ast.ArrayType{
+								Elt: ast.NewIdent("byte"),
+							},
+						},
+					},
+					{
+						Names: []*ast.Ident{
+							ast.NewIdent("n"),
+						},
+						Type: ast.NewIdent("uint64"),
+					},
+				},
+			},
+			Results: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Type: &ast.ArrayType{
+							Elt: ast.NewIdent("byte"),
+						},
+					},
+				},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("n"),
+					},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						&ast.CallExpr{
+							Fun: ast.NewIdent("min"),
+							Args: []ast.Expr{
+								ast.NewIdent("n"),
+								&ast.CallExpr{
+									Fun: ast.NewIdent("uint64"),
+									Args: []ast.Expr{
+										&ast.CallExpr{
+											Fun:  ast.NewIdent("len"),
+											Args: []ast.Expr{buf},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{
+						&ast.SliceExpr{
+							X: &ast.ParenExpr{
+								X: buf,
+							},
+							High:   ast.NewIdent("n"),
+							Max:    ast.NewIdent("n"),
+							Slice3: true,
+						},
+					},
+				},
+				&ast.AssignStmt{
+					Lhs: []ast.Expr{buf},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{
+						&ast.SliceExpr{
+							X: &ast.ParenExpr{
+								X: buf,
+							},
+							Low: ast.NewIdent("n"),
+						},
+					},
+				},
+				&ast.ReturnStmt{
+					Results: []ast.Expr{
+						ast.NewIdent("b"),
+					},
+				},
+			},
+		},
+	}
+}